@@ -0,0 +1,116 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package app
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+const (
+	AmountSyntaxError    = ErrorKind("invalid amount")
+	AmountPrecisionError = ErrorKind("too many decimal places")
+	AmountOverflowError  = ErrorKind("amount overflows")
+	AmountLotSizeError   = ErrorKind("amount is not a multiple of the lot size")
+
+	// MaxDecimals is the most decimal places an asset may have, since 10^19 is
+	// the largest power of ten that fits in a uint64.
+	MaxDecimals = 19
+)
+
+// pow10 returns 10^n for n <= MaxDecimals.
+func pow10(n uint8) uint64 {
+	p := uint64(1)
+	for i := uint8(0); i < n; i++ {
+		p *= 10
+	}
+	return p
+}
+
+// ParseAtoms parses a decimal string in conventional units, e.g. "1.5", into
+// integer atoms of an asset with the given number of decimal places, without
+// the rounding of floating point conversion. Trailing zeros beyond the
+// decimal places are allowed, any other extra precision is an error.
+func ParseAtoms(s string, decimals uint8) (uint64, error) {
+	if decimals > MaxDecimals {
+		return 0, fmt.Errorf("%d decimal places exceeds the maximum of %d", decimals, MaxDecimals)
+	}
+	intPart, fracPart, err := splitDecimal(s)
+	if err != nil {
+		return 0, err
+	}
+	if len(fracPart) > int(decimals) {
+		return 0, NewError(AmountPrecisionError, fmt.Sprintf("%q has more than %d decimal places", s, decimals))
+	}
+
+	overflow := NewError(AmountOverflowError, fmt.Sprintf("%q is too large", s))
+	var atoms uint64
+	for _, digits := range []string{intPart, fracPart} {
+		for _, d := range digits {
+			hi, lo := bits.Mul64(atoms, 10)
+			if hi != 0 {
+				return 0, overflow
+			}
+			var carry uint64
+			atoms, carry = bits.Add64(lo, uint64(d-'0'), 0)
+			if carry != 0 {
+				return 0, overflow
+			}
+		}
+	}
+	hi, atoms := bits.Mul64(atoms, pow10(decimals-uint8(len(fracPart))))
+	if hi != 0 {
+		return 0, overflow
+	}
+	return atoms, nil
+}
+
+// splitDecimal splits a non-negative decimal number into its integer and
+// fractional digits, without the fraction's trailing zeros.
+func splitDecimal(s string) (intPart, fracPart string, err error) {
+	s = strings.TrimSpace(s)
+	intPart = s
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return "", "", NewError(AmountSyntaxError, fmt.Sprintf("%q is not a decimal number", s))
+	}
+	return intPart, strings.TrimRight(fracPart, "0"), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseAmount parses a decimal string in the asset's conventional units into
+// atoms, which must be a multiple of the asset's lot size.
+func (a *Asset) ParseAmount(s string) (uint64, error) {
+	atoms, err := ParseAtoms(s, a.Decimals)
+	if err != nil {
+		return 0, err
+	}
+	if a.LotSize > 0 && atoms%a.LotSize != 0 {
+		return 0, NewError(AmountLotSizeError, fmt.Sprintf("%s %s is not a multiple of the lot size %s",
+			s, a.Symbol, FormatAtoms(a.LotSize, a.Decimals)))
+	}
+	return atoms, nil
+}
+
+// FormatAtoms formats atoms as a decimal string in conventional units, without
+// trailing zeros.
+func FormatAtoms(atoms uint64, decimals uint8) string {
+	if decimals == 0 {
+		return fmt.Sprintf("%d", atoms)
+	}
+	p := pow10(decimals)
+	s := fmt.Sprintf("%d.%0*d", atoms/p, decimals, atoms%p)
+	return strings.TrimRight(strings.TrimRight(s, "0"), ".")
+}
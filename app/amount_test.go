@@ -0,0 +1,95 @@
+package app
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAtoms(t *testing.T) {
+	tests := []struct {
+		s        string
+		decimals uint8
+		exp      uint64
+		wantErr  error
+	}{
+		{s: "1", decimals: 8, exp: 1e8},
+		{s: "0.1", decimals: 8, exp: 1e7},
+		{s: ".5", decimals: 8, exp: 5e7},
+		{s: "1.", decimals: 8, exp: 1e8},
+		{s: " 21.00000001 ", decimals: 8, exp: 2100000001},
+		{s: "0.123456780000", decimals: 8, exp: 12345678},
+		{s: "0", decimals: 8, exp: 0},
+		{s: "42", decimals: 0, exp: 42},
+		{s: "18446744073709551615", decimals: 0, exp: 18446744073709551615},
+		{s: "184467440737.09551615", decimals: 8, exp: 18446744073709551615},
+		{s: "184467440737.09551616", decimals: 8, wantErr: AmountOverflowError},
+		{s: "18446744073709551616", decimals: 0, wantErr: AmountOverflowError},
+		{s: "1000000000000", decimals: 8, wantErr: AmountOverflowError},
+		{s: "0.000000001", decimals: 8, wantErr: AmountPrecisionError},
+		{s: "1.5", decimals: 0, wantErr: AmountPrecisionError},
+		{s: "", decimals: 8, wantErr: AmountSyntaxError},
+		{s: ".", decimals: 8, wantErr: AmountSyntaxError},
+		{s: "-1", decimals: 8, wantErr: AmountSyntaxError},
+		{s: "+1", decimals: 8, wantErr: AmountSyntaxError},
+		{s: "1e8", decimals: 8, wantErr: AmountSyntaxError},
+		{s: "1.2.3", decimals: 8, wantErr: AmountSyntaxError},
+		{s: "0x10", decimals: 8, wantErr: AmountSyntaxError},
+	}
+	for _, tt := range tests {
+		atoms, err := ParseAtoms(tt.s, tt.decimals)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseAtoms(%q, %d): expected error %v, got %v", tt.s, tt.decimals, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAtoms(%q, %d): unexpected error: %v", tt.s, tt.decimals, err)
+			continue
+		}
+		if atoms != tt.exp {
+			t.Errorf("ParseAtoms(%q, %d): expected %d, got %d", tt.s, tt.decimals, tt.exp, atoms)
+		}
+	}
+
+	if _, err := ParseAtoms("1", MaxDecimals+1); err == nil {
+		t.Errorf("no error for %d decimal places", MaxDecimals+1)
+	}
+}
+
+func TestAssetParseAmount(t *testing.T) {
+	a := &Asset{Symbol: "btc", Decimals: 8, LotSize: 100000}
+	atoms, err := a.ParseAmount("0.012")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if atoms != 1200000 {
+		t.Fatalf("expected 1200000 atoms, got %d", atoms)
+	}
+	if _, err = a.ParseAmount("0.01234"); !errors.Is(err, AmountLotSizeError) {
+		t.Fatalf("expected lot size error, got %v", err)
+	}
+}
+
+func TestFormatAtoms(t *testing.T) {
+	tests := []struct {
+		atoms    uint64
+		decimals uint8
+		exp      string
+	}{
+		{1e8, 8, "1"},
+		{12345678, 8, "0.12345678"},
+		{150000000, 8, "1.5"},
+		{0, 8, "0"},
+		{42, 0, "42"},
+	}
+	for _, tt := range tests {
+		if s := FormatAtoms(tt.atoms, tt.decimals); s != tt.exp {
+			t.Errorf("FormatAtoms(%d, %d): expected %q, got %q", tt.atoms, tt.decimals, tt.exp, s)
+		}
+		atoms, err := ParseAtoms(tt.exp, tt.decimals)
+		if err != nil || atoms != tt.atoms {
+			t.Errorf("ParseAtoms(%q, %d) did not round trip: %d, %v", tt.exp, tt.decimals, atoms, err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package app
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -26,46 +27,90 @@ var (
 	testLockTimeMaker string
 
 	testLockTime struct {
+		sync.RWMutex
 		taker time.Duration
 		maker time.Duration
+		err   error
 	}
 )
 
-// Set test locktime values on init. Panics if invalid or 0-second duration
-// strings are provided.
+// Set test locktime values on init. If invalid duration strings are provided,
+// the error is returned by TestLockTimeError, and by LockTimeTaker and
+// LockTimeMaker on test networks, so that a misconfigured build fails closed.
 func init() {
+	testLockTime.taker, testLockTime.maker = defaultLockTimeTaker, defaultlockTimeMaker
 	if testLockTimeTaker == "" && testLockTimeMaker == "" {
-		testLockTime.taker, testLockTime.maker = defaultLockTimeTaker, defaultlockTimeMaker
 		return
 	}
-	testLockTime.taker, _ = time.ParseDuration(testLockTimeTaker)
-	if testLockTime.taker.Seconds() == 0 {
-		panic(fmt.Sprintf("invalid value for testLockTimeTaker: %q", testLockTimeTaker))
+	taker, err := time.ParseDuration(testLockTimeTaker)
+	if err != nil {
+		testLockTime.err = fmt.Errorf("invalid value for testLockTimeTaker: %q: %w", testLockTimeTaker, err)
+		return
 	}
-	testLockTime.maker, _ = time.ParseDuration(testLockTimeMaker)
-	if testLockTime.maker.Seconds() == 0 {
-		panic(fmt.Sprintf("invalid value for testLockTimeMaker: %q", testLockTimeMaker))
+	maker, err := time.ParseDuration(testLockTimeMaker)
+	if err != nil {
+		testLockTime.err = fmt.Errorf("invalid value for testLockTimeMaker: %q: %w", testLockTimeMaker, err)
+		return
 	}
+	testLockTime.err = SetTestLockTimes(taker, maker)
+}
+
+// TestLockTimeError returns the error from the build-time test locktime
+// values, which leaves the test networks without locktimes until
+// SetTestLockTimes succeeds. Programs may check it at startup.
+func TestLockTimeError() error {
+	testLockTime.RLock()
+	defer testLockTime.RUnlock()
+	return testLockTime.err
+}
+
+// SetTestLockTimes sets the locktimes used on test networks. The taker locktime
+// must be positive and the maker locktime must exceed it, with no margin
+// required, e.g. 30s and 1m on simnet.
+func SetTestLockTimes(taker, maker time.Duration) error {
+	lt := MarketLockTimes{Taker: taker, Maker: maker}
+	if err := lt.Validate(0); err != nil {
+		return err
+	}
+	testLockTime.Lock()
+	testLockTime.taker, testLockTime.maker = taker, maker
+	testLockTime.err = nil
+	testLockTime.Unlock()
+	return nil
 }
 
 // LockTimeTaker returns the taker locktime value that should be used by both
 // client and server for the specified network. Mainnet uses a constant value
-// while test networks support setting a custom value during build.
-func LockTimeTaker(network Network) time.Duration {
+// while test networks support setting a custom value during build or with
+// SetTestLockTimes. It is an error to use a test network if the build-time
+// test locktimes are invalid.
+func LockTimeTaker(network Network) (time.Duration, error) {
 	if network == Mainnet {
-		return defaultLockTimeTaker
+		return defaultLockTimeTaker, nil
 	}
-	return testLockTime.taker
+	testLockTime.RLock()
+	defer testLockTime.RUnlock()
+	if testLockTime.err != nil {
+		return 0, testLockTime.err
+	}
+	return testLockTime.taker, nil
 }
 
 // LockTimeMaker returns the maker locktime value that should be used by both
 // client and server for the specified network. Mainnet uses a constant value
-// while test networks support setting a custom value during build.
-func LockTimeMaker(network Network) time.Duration {
+// while test networks support setting a custom value during build or with
+// SetTestLockTimes. It is an error to use a test network if the build-time
+// test locktimes are invalid.
+func LockTimeMaker(network Network) (time.Duration, error) {
 	if network == Mainnet {
-		return defaultlockTimeMaker
+		return defaultlockTimeMaker, nil
+	}
+	testLockTime.RLock()
+	defer testLockTime.RUnlock()
+	if testLockTime.err != nil {
+		return 0, testLockTime.err
 	}
-	return testLockTime.maker
+	return testLockTime.maker, nil
 }
 
 // Network flags passed to asset backends to signify which network to use.
@@ -110,10 +155,14 @@ func NetFromString(net string) (Network, error) {
 type Asset struct {
 	ID           uint32 `json:"id"`
 	Symbol       string `json:"symbol"`
+	Decimals     uint8  `json:"decimals"`
 	LotSize      uint64 `json:"lotSize"`
 	RateStep     uint64 `json:"rateStep"`
 	MaxFeeRate   uint64 `json:"maxFeeRate"`
 	SwapSize     uint64 `json:"swapSize"`
 	SwapSizeBase uint64 `json:"swapSizeBase"`
+	RedeemSize   uint64 `json:"redeemSize"`
+	RefundSize   uint64 `json:"refundSize"`
 	SwapConf     uint32 `json:"swapConf"`
+	BlockTime    uint64 `json:"blockTime"` // msec
 }
@@ -3,26 +3,43 @@
 
 package app
 
-var symbolBipIDs map[string]uint32
+import "sync"
+
+var (
+	symbolBipIDs     map[string]uint32
+	symbolBipIDsOnce sync.Once
+)
 
 // BipSymbolID returns the asset ID associated with a given ticker symbol.
 // While there are a number of duplicate ticker symbols in the BIP ID list
 // (cpc, cmt, xrd, dst, one, ask, ...), those are disambiguated in the bipIDs
-// map here, so must be referenced with their bracketed suffix.
+// map here, so must be referenced with their bracketed suffix. Token symbols
+// are suffixed with their parent chain's symbol, e.g. "usdc.eth".
 func BipSymbolID(symbol string) (uint32, bool) {
-	if symbolBipIDs == nil {
-		symbolBipIDs = make(map[string]uint32)
+	symbolBipIDsOnce.Do(func() {
+		symbolBipIDs = make(map[string]uint32, len(bipIDs)+len(tokens))
 		for idx, sym := range bipIDs {
 			symbolBipIDs[sym] = idx
 		}
-	}
+		for id := range tokens {
+			symbolBipIDs[BipIDSymbol(id)] = id
+		}
+	})
 
 	idx, found := symbolBipIDs[symbol]
 	return idx, found
 }
 
-// BipIDSymbol returns the BIP ID for a given symbol.
+// BipIDSymbol returns the BIP ID for a given symbol. The symbol of a token is
+// suffixed with its parent chain's symbol, e.g. "usdc.eth".
 func BipIDSymbol(id uint32) string {
+	if IsToken(id) {
+		token, found := tokens[id]
+		if !found {
+			return ""
+		}
+		return token + "." + bipIDs[TokenParentID(id)]
+	}
 	return bipIDs[id]
 }
 
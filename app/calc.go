@@ -0,0 +1,115 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"strings"
+)
+
+const (
+	ZeroRateError = ErrorKind("zero rate")
+
+	// RateEncodingFactor is the factor by which rates are multiplied to encode
+	// them as integers. A rate is the number of quote asset atoms per base
+	// asset atom, times RateEncodingFactor.
+	RateEncodingFactor = 1e8
+)
+
+// BaseToQuote computes the quantity of quote asset atoms that trade for the
+// base asset atoms at the rate, truncating any fraction of an atom.
+func BaseToQuote(rate, base uint64) (uint64, error) {
+	hi, lo := bits.Mul64(rate, base)
+	if hi >= RateEncodingFactor {
+		return 0, NewError(AmountOverflowError, fmt.Sprintf("%d base atoms at rate %d", base, rate))
+	}
+	quote, _ := bits.Div64(hi, lo, RateEncodingFactor)
+	return quote, nil
+}
+
+// QuoteToBase computes the quantity of base asset atoms that trade for the
+// quote asset atoms at the rate, truncating any fraction of an atom.
+func QuoteToBase(rate, quote uint64) (uint64, error) {
+	if rate == 0 {
+		return 0, ZeroRateError
+	}
+	hi, lo := bits.Mul64(quote, RateEncodingFactor)
+	if hi >= rate {
+		return 0, NewError(AmountOverflowError, fmt.Sprintf("%d quote atoms at rate %d", quote, rate))
+	}
+	base, _ := bits.Div64(hi, lo, rate)
+	return base, nil
+}
+
+// RoundRate rounds the rate to the nearest multiple of the rate step, rounding
+// halves up unless that would overflow.
+func RoundRate(rate, rateStep uint64) uint64 {
+	if rateStep == 0 {
+		return rate
+	}
+	rem := rate % rateStep
+	down := rate - rem
+	if rem >= rateStep-rem && down <= math.MaxUint64-rateStep {
+		return down + rateStep
+	}
+	return down
+}
+
+// rateDecimals is the number of decimal places of the rate when it is expressed
+// as a conventional price, in quote units per base unit. It is negative if the
+// conventional price is a multiple of a power of ten.
+func rateDecimals(baseDecimals, quoteDecimals uint8) int {
+	return 8 + int(quoteDecimals) - int(baseDecimals)
+}
+
+// ConventionalRate formats the rate as a decimal price in conventional quote
+// units per conventional base unit, e.g. "0.0123" BTC per DCR, without the
+// rounding of floating point conversion.
+func ConventionalRate(rate uint64, baseDecimals, quoteDecimals uint8) string {
+	decimals := rateDecimals(baseDecimals, quoteDecimals)
+	n := new(big.Int).SetUint64(rate)
+	if decimals <= 0 {
+		return n.Mul(n, pow10Big(-decimals)).String()
+	}
+	digits := n.String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	s := digits[:len(digits)-decimals] + "." + digits[len(digits)-decimals:]
+	return strings.TrimRight(strings.TrimRight(s, "0"), ".")
+}
+
+// ParseConventionalRate parses a decimal price in conventional quote units per
+// conventional base unit into an encoded rate. It is an error if the price has
+// more precision than the rate can encode, or if the rate overflows.
+func ParseConventionalRate(price string, baseDecimals, quoteDecimals uint8) (uint64, error) {
+	intPart, fracPart, err := splitDecimal(price)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		n = new(big.Int)
+	}
+	exp := rateDecimals(baseDecimals, quoteDecimals) - len(fracPart)
+	if exp >= 0 {
+		n.Mul(n, pow10Big(exp))
+	} else {
+		var rem big.Int
+		if n.QuoRem(n, pow10Big(-exp), &rem); rem.Sign() != 0 {
+			return 0, NewError(AmountPrecisionError, fmt.Sprintf("price %q is more precise than a rate", price))
+		}
+	}
+	if !n.IsUint64() {
+		return 0, NewError(AmountOverflowError, fmt.Sprintf("price %q is too large", price))
+	}
+	return n.Uint64(), nil
+}
+
+func pow10Big(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestBaseToQuote(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    uint64
+		base    uint64
+		exp     uint64
+		wantErr error
+	}{
+		{"one to one", 1e8, 5e8, 5e8, nil},
+		{"fractional rate", 1234567, 1e8, 1234567, nil},
+		{"truncated", 3, 1, 0, nil},
+		{"zero base", 1234567, 0, 0, nil},
+		{"max result", 1e8, math.MaxUint64, math.MaxUint64, nil},
+		{"overflow", 1e8 + 1, math.MaxUint64, 0, AmountOverflowError},
+		{"max overflow", math.MaxUint64, math.MaxUint64, 0, AmountOverflowError},
+	}
+	for _, tt := range tests {
+		quote, err := BaseToQuote(tt.rate, tt.base)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			continue
+		}
+		if quote != tt.exp {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.exp, quote)
+		}
+	}
+}
+
+func TestQuoteToBase(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    uint64
+		quote   uint64
+		exp     uint64
+		wantErr error
+	}{
+		{"one to one", 1e8, 5e8, 5e8, nil},
+		{"fractional rate", 1234567, 1234567, 1e8, nil},
+		{"truncated", 2e8, 3, 1, nil},
+		{"zero rate", 0, 1, 0, ZeroRateError},
+		{"max result", 1e8, math.MaxUint64, math.MaxUint64, nil},
+		{"overflow", 1, math.MaxUint64, 0, AmountOverflowError},
+	}
+	for _, tt := range tests {
+		base, err := QuoteToBase(tt.rate, tt.quote)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			continue
+		}
+		if base != tt.exp {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.exp, base)
+		}
+	}
+}
+
+func TestRoundRate(t *testing.T) {
+	tests := []struct {
+		rate, step, exp uint64
+	}{
+		{1234567, 1000, 1235000},
+		{1234499, 1000, 1234000},
+		{1234500, 1000, 1235000},
+		{1234000, 1000, 1234000},
+		{1234567, 0, 1234567},
+		{1, 1000, 0},
+		{math.MaxUint64, 10, math.MaxUint64 - 5},
+	}
+	for _, tt := range tests {
+		if r := RoundRate(tt.rate, tt.step); r != tt.exp {
+			t.Errorf("RoundRate(%d, %d): expected %d, got %d", tt.rate, tt.step, tt.exp, r)
+		}
+	}
+}
+
+func TestConventionalRate(t *testing.T) {
+	tests := []struct {
+		rate          uint64
+		baseDecimals  uint8
+		quoteDecimals uint8
+		price         string
+	}{
+		{1234567, 8, 8, "0.01234567"},
+		{1e8, 8, 8, "1"},
+		{150000000, 8, 8, "1.5"},
+		{0, 8, 8, "0"},
+		{math.MaxUint64, 8, 8, "184467440737.09551615"},
+		{5, 18, 8, "500"},
+		{12345678901, 8, 6, "12345.678901"},
+	}
+	for _, tt := range tests {
+		price := ConventionalRate(tt.rate, tt.baseDecimals, tt.quoteDecimals)
+		if price != tt.price {
+			t.Errorf("ConventionalRate(%d, %d, %d): expected %q, got %q", tt.rate, tt.baseDecimals,
+				tt.quoteDecimals, tt.price, price)
+		}
+		rate, err := ParseConventionalRate(tt.price, tt.baseDecimals, tt.quoteDecimals)
+		if err != nil || rate != tt.rate {
+			t.Errorf("ParseConventionalRate(%q, %d, %d): expected %d, got %d, %v", tt.price, tt.baseDecimals,
+				tt.quoteDecimals, tt.rate, rate, err)
+		}
+	}
+}
+
+func TestParseConventionalRateErrors(t *testing.T) {
+	tests := []struct {
+		price         string
+		baseDecimals  uint8
+		quoteDecimals uint8
+		wantErr       error
+	}{
+		{"0.000000001", 8, 8, AmountPrecisionError},
+		{"501", 18, 8, AmountPrecisionError},
+		{"184467440737.09551616", 8, 8, AmountOverflowError},
+		{"abc", 8, 8, AmountSyntaxError},
+		{"-1", 8, 8, AmountSyntaxError},
+	}
+	for _, tt := range tests {
+		if _, err := ParseConventionalRate(tt.price, tt.baseDecimals, tt.quoteDecimals); !errors.Is(err, tt.wantErr) {
+			t.Errorf("ParseConventionalRate(%q, %d, %d): expected error %v, got %v", tt.price, tt.baseDecimals,
+				tt.quoteDecimals, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package app
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+const (
+	InvalidCoinIDError = ErrorKind("invalid coin ID")
+
+	// UTXOCoinIDSize is the size of the coin ID of a UTXO, a 32-byte
+	// transaction hash and a 4-byte big-endian output index.
+	UTXOCoinIDSize = 36
+)
+
+// CoinIDDecoder converts an asset's coin ID to its human-readable form,
+// returning an InvalidCoinIDError if it is malformed.
+type CoinIDDecoder func(coinID []byte) (string, error)
+
+var coinIDDecoders = struct {
+	sync.RWMutex
+	decoders map[uint32]CoinIDDecoder
+}{
+	decoders: make(map[uint32]CoinIDDecoder),
+}
+
+func init() {
+	for _, symbol := range []string{"btc", "ltc", "dcr"} {
+		id, _ := BipSymbolID(symbol)
+		RegisterCoinIDDecoder(id, DecodeUTXOCoinID)
+	}
+}
+
+// RegisterCoinIDDecoder sets the coin ID decoder of the asset.
+func RegisterCoinIDDecoder(assetID uint32, dec CoinIDDecoder) {
+	coinIDDecoders.Lock()
+	coinIDDecoders.decoders[assetID] = dec
+	coinIDDecoders.Unlock()
+}
+
+// DecodeCoinID converts the asset's coin ID to its human-readable form,
+// returning an InvalidCoinIDError if it is malformed or the asset has no
+// decoder.
+func DecodeCoinID(assetID uint32, coinID []byte) (string, error) {
+	coinIDDecoders.RLock()
+	dec, found := coinIDDecoders.decoders[assetID]
+	coinIDDecoders.RUnlock()
+	if !found {
+		return "", NewError(InvalidCoinIDError, fmt.Sprintf("no coin ID decoder for asset %d", assetID))
+	}
+	return dec(coinID)
+}
+
+// ValidateCoinID checks that the coin ID is well-formed for the asset. Assets
+// without a registered decoder, such as tokens, have coin IDs of any format, so
+// only an empty coin ID is invalid.
+func ValidateCoinID(assetID uint32, coinID []byte) error {
+	coinIDDecoders.RLock()
+	dec, found := coinIDDecoders.decoders[assetID]
+	coinIDDecoders.RUnlock()
+	if !found {
+		if len(coinID) == 0 {
+			return NewError(InvalidCoinIDError, fmt.Sprintf("empty coin ID for asset %d", assetID))
+		}
+		return nil
+	}
+	_, err := dec(coinID)
+	return err
+}
+
+// ParseUTXOCoinID splits a UTXO coin ID into its transaction hash and output
+// index.
+func ParseUTXOCoinID(coinID []byte) (txHash [32]byte, vout uint32, err error) {
+	if len(coinID) != UTXOCoinIDSize {
+		return txHash, 0, NewError(InvalidCoinIDError, fmt.Sprintf("UTXO coin ID is %d bytes, expected %d",
+			len(coinID), UTXOCoinIDSize))
+	}
+	copy(txHash[:], coinID[:32])
+	return txHash, binary.BigEndian.Uint32(coinID[32:]), nil
+}
+
+// DecodeUTXOCoinID is the CoinIDDecoder of UTXO chains, giving txid:vout. As
+// is conventional, the txid is the transaction hash in reverse byte order.
+func DecodeUTXOCoinID(coinID []byte) (string, error) {
+	txHash, vout, err := ParseUTXOCoinID(coinID)
+	if err != nil {
+		return "", err
+	}
+	for i, j := 0, len(txHash)-1; i < j; i, j = i+1, j-1 {
+		txHash[i], txHash[j] = txHash[j], txHash[i]
+	}
+	return fmt.Sprintf("%s:%d", hex.EncodeToString(txHash[:]), vout), nil
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeCoinID(t *testing.T) {
+	coinID := make([]byte, UTXOCoinIDSize)
+	coinID[0] = 0xab
+	coinID[35] = 3
+	for _, symbol := range []string{"btc", "ltc", "dcr"} {
+		id, _ := BipSymbolID(symbol)
+		s, err := DecodeCoinID(id, coinID)
+		if err != nil {
+			t.Fatalf("%s DecodeCoinID error: %v", symbol, err)
+		}
+		if !strings.HasSuffix(s, "ab:3") || len(s) != 64+2 {
+			t.Fatalf("%s coin ID string %q", symbol, s)
+		}
+	}
+	if _, err := DecodeCoinID(0, coinID[:35]); !errors.Is(err, InvalidCoinIDError) {
+		t.Fatalf("expected invalid coin ID error, got %v", err)
+	}
+	if _, err := DecodeCoinID(USDCEthID, coinID); !errors.Is(err, InvalidCoinIDError) {
+		t.Fatalf("expected invalid coin ID error for an asset without a decoder, got %v", err)
+	}
+	// Coin IDs of an asset without a decoder need only be non-empty.
+	if err := ValidateCoinID(0, coinID[:35]); !errors.Is(err, InvalidCoinIDError) {
+		t.Fatalf("expected invalid coin ID error, got %v", err)
+	}
+	if err := ValidateCoinID(USDCEthID, coinID[:20]); err != nil {
+		t.Fatalf("token coin ID rejected: %v", err)
+	}
+	if err := ValidateCoinID(USDCEthID, nil); !errors.Is(err, InvalidCoinIDError) {
+		t.Fatalf("expected invalid coin ID error for an empty coin ID, got %v", err)
+	}
+	txHash, vout, err := ParseUTXOCoinID(coinID)
+	if err != nil || txHash[0] != 0xab || vout != 3 {
+		t.Fatalf("ParseUTXOCoinID returned %x:%d, err = %v", txHash, vout, err)
+	}
+}
@@ -0,0 +1,265 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package app
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode is a stable numeric error code shared by the server and clients.
+// Codes are grouped by hundreds: general errors below 100, then order,
+// account, market, swap and auth errors. Codes must never be renumbered.
+type ErrorCode uint16
+
+// General errors. These map to the standard JSON-RPC 2.0 error codes.
+const (
+	CodeParse          ErrorCode = 1
+	CodeInvalidRequest ErrorCode = 2
+	CodeUnknownMethod  ErrorCode = 3
+	CodeInvalidParams  ErrorCode = 4
+	CodeInternal       ErrorCode = 5
+)
+
+// Order errors.
+const (
+	CodeOrderParameter   ErrorCode = 100
+	CodeOrderLotSize     ErrorCode = 101
+	CodeOrderRateStep    ErrorCode = 102
+	CodeOrderFunding     ErrorCode = 103
+	CodeOrderNotFound    ErrorCode = 104
+	CodeDuplicateOrder   ErrorCode = 105
+	CodeCancelNotAllowed ErrorCode = 106
+	CodeOrderQuantity    ErrorCode = 107
+	CodeMarketBuy        ErrorCode = 108
+	CodeFeeRate          ErrorCode = 109
+	CodeCoinID           ErrorCode = 110
+)
+
+// Account errors.
+const (
+	CodeAccountNotFound  ErrorCode = 200
+	CodeAccountExists    ErrorCode = 201
+	CodeAccountSuspended ErrorCode = 202
+	CodeFeeUnpaid        ErrorCode = 203
+	CodeFeePayment       ErrorCode = 204
+)
+
+// Market errors.
+const (
+	CodeUnknownMarket    ErrorCode = 300
+	CodeMarketNotRunning ErrorCode = 301
+	CodeMarketSuspended  ErrorCode = 302
+	CodeUnknownAsset     ErrorCode = 303
+)
+
+// Swap errors.
+const (
+	CodeMatchNotFound ErrorCode = 400
+	CodeContract      ErrorCode = 401
+	CodeSecret        ErrorCode = 402
+	CodeRedemption    ErrorCode = 403
+	CodeTxNotFound    ErrorCode = 404
+	CodeSwapTimeout   ErrorCode = 405
+	CodeLockTime      ErrorCode = 406
+)
+
+// Auth errors.
+const (
+	CodeSignature      ErrorCode = 500
+	CodePubKey         ErrorCode = 501
+	CodeUnauthorized   ErrorCode = 502
+	CodeSessionExpired ErrorCode = 503
+)
+
+// codeInfo is an error code's name, JSON-RPC error code and message. The
+// message is shown to clients.
+type codeInfo struct {
+	name    string
+	rpcCode int
+	msg     string
+}
+
+var errorCodes = map[ErrorCode]codeInfo{
+	CodeParse:          {"CodeParse", -32700, "parse error"},
+	CodeInvalidRequest: {"CodeInvalidRequest", -32600, "invalid request"},
+	CodeUnknownMethod:  {"CodeUnknownMethod", -32601, "method not found"},
+	CodeInvalidParams:  {"CodeInvalidParams", -32602, "invalid params"},
+	CodeInternal:       {"CodeInternal", -32603, "internal error"},
+
+	CodeOrderParameter:   {"CodeOrderParameter", 0, "invalid order parameters"},
+	CodeOrderLotSize:     {"CodeOrderLotSize", 0, "quantity is not a multiple of the lot size"},
+	CodeOrderRateStep:    {"CodeOrderRateStep", 0, "rate is not a multiple of the rate step"},
+	CodeOrderFunding:     {"CodeOrderFunding", 0, "order is not sufficiently funded"},
+	CodeOrderNotFound:    {"CodeOrderNotFound", 0, "order not found"},
+	CodeDuplicateOrder:   {"CodeDuplicateOrder", 0, "duplicate order"},
+	CodeCancelNotAllowed: {"CodeCancelNotAllowed", 0, "order cannot be canceled"},
+	CodeOrderQuantity:    {"CodeOrderQuantity", 0, "order quantity exceeds the limit"},
+	CodeMarketBuy:        {"CodeMarketBuy", 0, "market buy quantity too small"},
+	CodeFeeRate:          {"CodeFeeRate", 0, "unacceptable fee rate"},
+	CodeCoinID:           {"CodeCoinID", 0, "invalid coin ID"},
+
+	CodeAccountNotFound:  {"CodeAccountNotFound", 0, "account not found"},
+	CodeAccountExists:    {"CodeAccountExists", 0, "account already exists"},
+	CodeAccountSuspended: {"CodeAccountSuspended", 0, "account suspended"},
+	CodeFeeUnpaid:        {"CodeFeeUnpaid", 0, "registration fee not paid"},
+	CodeFeePayment:       {"CodeFeePayment", 0, "invalid registration fee payment"},
+
+	CodeUnknownMarket:    {"CodeUnknownMarket", 0, "unknown market"},
+	CodeMarketNotRunning: {"CodeMarketNotRunning", 0, "market not running"},
+	CodeMarketSuspended:  {"CodeMarketSuspended", 0, "market suspended"},
+	CodeUnknownAsset:     {"CodeUnknownAsset", 0, "unknown asset"},
+
+	CodeMatchNotFound: {"CodeMatchNotFound", 0, "match not found"},
+	CodeContract:      {"CodeContract", 0, "invalid swap contract"},
+	CodeSecret:        {"CodeSecret", 0, "invalid secret"},
+	CodeRedemption:    {"CodeRedemption", 0, "invalid redemption"},
+	CodeTxNotFound:    {"CodeTxNotFound", 0, "transaction not found"},
+	CodeSwapTimeout:   {"CodeSwapTimeout", 0, "swap step timed out"},
+	CodeLockTime:      {"CodeLockTime", 0, "invalid locktime"},
+
+	CodeSignature:      {"CodeSignature", 0, "invalid signature"},
+	CodePubKey:         {"CodePubKey", 0, "invalid public key"},
+	CodeUnauthorized:   {"CodeUnauthorized", 0, "unauthorized"},
+	CodeSessionExpired: {"CodeSessionExpired", 0, "session expired"},
+}
+
+// kindCodes is the error code of every ErrorKind, used when an error reaches a
+// client without a code of its own. Kinds that only result from a server
+// misconfiguration are internal errors.
+var kindCodes = map[ErrorKind]ErrorCode{
+	AmountSyntaxError:      CodeInvalidParams,
+	AmountPrecisionError:   CodeInvalidParams,
+	AmountOverflowError:    CodeOrderQuantity,
+	AmountLotSizeError:     CodeOrderLotSize,
+	ZeroRateError:          CodeOrderParameter,
+	UnsupportedScriptError: CodeInvalidParams,
+	InvalidCoinIDError:     CodeCoinID,
+	FeeRateError:           CodeFeeRate,
+	InsufficientFundsError: CodeOrderFunding,
+	InvalidLockTimeError:   CodeLockTime,
+	InvalidMarketError:     CodeUnknownMarket,
+	MarketBuyError:         CodeMarketBuy,
+	UnknownAssetError:      CodeUnknownAsset,
+	InvalidAssetError:      CodeInternal,
+}
+
+// Code returns the kind's error code, or CodeInternal for a kind without one.
+func (e ErrorKind) Code() ErrorCode {
+	if code, found := kindCodes[e]; found {
+		return code
+	}
+	return CodeInternal
+}
+
+// String returns the code's name.
+func (c ErrorCode) String() string {
+	if info, found := errorCodes[c]; found {
+		return info.name
+	}
+	return fmt.Sprintf("ErrorCode(%d)", uint16(c))
+}
+
+// Error satisfies the error interface with the code's client-safe message,
+// so that codes can be used as errors and with errors.Is.
+func (c ErrorCode) Error() string {
+	if info, found := errorCodes[c]; found {
+		return info.msg
+	}
+	return fmt.Sprintf("unknown error code %d", uint16(c))
+}
+
+// RPCCode is the code's JSON-RPC error code. The general errors use the
+// standard JSON-RPC 2.0 codes, and the others their own value, which is outside
+// the range reserved by JSON-RPC.
+func (c ErrorCode) RPCCode() int {
+	if info, found := errorCodes[c]; found && info.rpcCode != 0 {
+		return info.rpcCode
+	}
+	return int(c)
+}
+
+// CodedError pairs an error code with a detail that is safe to show clients,
+// and optionally the internal error that caused it, which is not.
+type CodedError struct {
+	Code   ErrorCode
+	Detail string
+	cause  error
+}
+
+// NewCodedError creates a CodedError with a client-safe detail.
+func NewCodedError(code ErrorCode, detail string) *CodedError {
+	return &CodedError{Code: code, Detail: detail}
+}
+
+// WrapCodedError creates a CodedError with a client-safe detail for the
+// internal error, which remains available to errors.Is and errors.As.
+func WrapCodedError(code ErrorCode, cause error, detail string) *CodedError {
+	return &CodedError{Code: code, Detail: detail, cause: cause}
+}
+
+// Error satisfies the error interface, including the internal cause.
+func (e *CodedError) Error() string {
+	msg := e.Code.Error()
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.cause != nil {
+		msg += ": " + e.cause.Error()
+	}
+	return msg
+}
+
+// Is matches the error's code, so that errors.Is(err, CodeOrderNotFound)
+// works through any wrapping.
+func (e *CodedError) Is(target error) bool {
+	code, ok := target.(ErrorCode)
+	return ok && code == e.Code
+}
+
+// Unwrap returns the internal cause.
+func (e *CodedError) Unwrap() error {
+	return e.cause
+}
+
+// RPCError is the error of a JSON-RPC response.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error satisfies the error interface.
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// NewRPCError converts an error to a JSON-RPC error response, showing only the
+// code's message and client-safe detail. An ErrorKind is converted to its code,
+// with the kind as the detail unless the code is internal. Other errors, and
+// the internal causes of coded errors and the details of kinds, are not
+// revealed.
+func NewRPCError(err error) *RPCError {
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		msg := coded.Code.Error()
+		if coded.Detail != "" {
+			msg += ": " + coded.Detail
+		}
+		return &RPCError{Code: coded.Code.RPCCode(), Message: msg}
+	}
+	var code ErrorCode
+	if errors.As(err, &code) {
+		return &RPCError{Code: code.RPCCode(), Message: code.Error()}
+	}
+	var kind ErrorKind
+	if errors.As(err, &kind) {
+		code := kind.Code()
+		msg := code.Error()
+		if code != CodeInternal && string(kind) != msg {
+			msg += ": " + string(kind)
+		}
+		return &RPCError{Code: code.RPCCode(), Message: msg}
+	}
+	return &RPCError{Code: CodeInternal.RPCCode(), Message: CodeInternal.Error()}
+}
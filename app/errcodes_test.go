@@ -0,0 +1,131 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCodes(t *testing.T) {
+	// Codes are part of the wire protocol and must not change.
+	for _, tt := range []struct {
+		code    ErrorCode
+		value   uint16
+		rpcCode int
+	}{
+		{CodeParse, 1, -32700},
+		{CodeInternal, 5, -32603},
+		{CodeOrderParameter, 100, 100},
+		{CodeAccountSuspended, 202, 202},
+		{CodeUnknownMarket, 300, 300},
+		{CodeSecret, 402, 402},
+		{CodeSignature, 500, 500},
+	} {
+		if uint16(tt.code) != tt.value || tt.code.RPCCode() != tt.rpcCode {
+			t.Errorf("%s: got %d/%d, want %d/%d", tt.code, uint16(tt.code), tt.code.RPCCode(), tt.value, tt.rpcCode)
+		}
+	}
+	for code, info := range errorCodes {
+		if info.name == "" || info.msg == "" {
+			t.Errorf("code %d has no name or message", code)
+		}
+	}
+	if s := ErrorCode(9999).String(); s != "ErrorCode(9999)" {
+		t.Errorf("unknown code string %q", s)
+	}
+}
+
+func TestKindCodes(t *testing.T) {
+	// Every ErrorKind must have a code, so that it reaches clients as more
+	// than an internal error. New kinds must be added here and to kindCodes.
+	for _, tt := range []struct {
+		kind ErrorKind
+		code ErrorCode
+	}{
+		{AmountSyntaxError, CodeInvalidParams},
+		{AmountPrecisionError, CodeInvalidParams},
+		{AmountOverflowError, CodeOrderQuantity},
+		{AmountLotSizeError, CodeOrderLotSize},
+		{ZeroRateError, CodeOrderParameter},
+		{UnsupportedScriptError, CodeInvalidParams},
+		{InvalidCoinIDError, CodeCoinID},
+		{FeeRateError, CodeFeeRate},
+		{InsufficientFundsError, CodeOrderFunding},
+		{InvalidLockTimeError, CodeLockTime},
+		{InvalidMarketError, CodeUnknownMarket},
+		{MarketBuyError, CodeMarketBuy},
+		{UnknownAssetError, CodeUnknownAsset},
+		{InvalidAssetError, CodeInternal},
+	} {
+		if code := tt.kind.Code(); code != tt.code {
+			t.Errorf("%q: got code %s, want %s", tt.kind, code, tt.code)
+		}
+		if _, found := kindCodes[tt.kind]; !found {
+			t.Errorf("%q has no error code", tt.kind)
+		}
+	}
+	for kind, code := range kindCodes {
+		if _, found := errorCodes[code]; !found {
+			t.Errorf("%q has unknown error code %d", kind, code)
+		}
+	}
+	if code := ErrorKind("no such kind").Code(); code != CodeInternal {
+		t.Errorf("unknown kind has code %s", code)
+	}
+}
+
+func TestCodedErrorWrapping(t *testing.T) {
+	cause := NewError(AmountOverflowError, "too many atoms")
+	err := fmt.Errorf("submit failed: %w", WrapCodedError(CodeOrderQuantity, cause, "quantity too high"))
+
+	if !errors.Is(err, CodeOrderQuantity) {
+		t.Fatalf("code not found")
+	}
+	if errors.Is(err, CodeOrderLotSize) {
+		t.Fatalf("wrong code matched")
+	}
+	if !errors.Is(err, AmountOverflowError) {
+		t.Fatalf("cause not found")
+	}
+	var coded *CodedError
+	if !errors.As(err, &coded) || coded.Code != CodeOrderQuantity {
+		t.Fatalf("errors.As failed")
+	}
+	var appErr Error
+	if !errors.As(err, &appErr) {
+		t.Fatalf("errors.As cause failed")
+	}
+}
+
+func TestNewRPCError(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want RPCError
+	}{
+		{"coded", NewCodedError(CodeUnknownMarket, "dcr_xyz"),
+			RPCError{300, "unknown market: dcr_xyz"}},
+		{"wrapped cause hidden", fmt.Errorf("ctx: %w", WrapCodedError(CodeOrderFunding, errors.New("db password wrong"), "")),
+			RPCError{103, "order is not sufficiently funded"}},
+		{"bare code", fmt.Errorf("ctx: %w", CodeInvalidParams),
+			RPCError{-32602, "invalid params"}},
+		{"kind", fmt.Errorf("ctx: %w", NewError(MarketBuyError, "buy 10 lots at rate 1")),
+			RPCError{108, "market buy quantity too small: market buy too small"}},
+		{"kind with a general code", NewError(AmountSyntaxError, "1.2.3"),
+			RPCError{-32602, "invalid params: invalid amount"}},
+		{"internal kind", NewError(InvalidAssetError, "btc: zero lot size"),
+			RPCError{-32603, "internal error"}},
+		{"internal", errors.New("db password wrong"),
+			RPCError{-32603, "internal error"}},
+	} {
+		got := NewRPCError(tt.err)
+		if *got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+	b, _ := json.Marshal(NewRPCError(NewCodedError(CodeSignature, "")))
+	if string(b) != `{"code":500,"message":"invalid signature"}` {
+		t.Errorf("unexpected JSON %s", b)
+	}
+}
@@ -0,0 +1,124 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package app
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+const (
+	FeeRateError           = ErrorKind("unacceptable fee rate")
+	InsufficientFundsError = ErrorKind("insufficient funds")
+)
+
+// mul multiplies, returning an AmountOverflowError if the product does not fit
+// in a uint64.
+func mul(a, b uint64) (uint64, error) {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return 0, NewError(AmountOverflowError, fmt.Sprintf("%d * %d", a, b))
+	}
+	return lo, nil
+}
+
+// add adds, returning an AmountOverflowError if the sum does not fit in a
+// uint64.
+func add(a, b uint64) (uint64, error) {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return 0, NewError(AmountOverflowError, fmt.Sprintf("%d + %d", a, b))
+	}
+	return sum, nil
+}
+
+// SwapTxSize is the worst-case total size of the swap transactions of an order
+// of the lots, funded by coins whose inputs total inputsSize bytes. In the worst
+// case every lot is matched separately. The first swap transaction spends the
+// funding coins, and each of the others spends the change of the one before,
+// which SwapSize already includes.
+func (a *Asset) SwapTxSize(lots, inputsSize uint64) (uint64, error) {
+	if lots == 0 {
+		return 0, nil
+	}
+	size, err := mul(lots-1, a.SwapSize)
+	if err != nil {
+		return 0, err
+	}
+	if size, err = add(size, a.SwapSizeBase); err != nil {
+		return 0, err
+	}
+	return add(size, inputsSize)
+}
+
+// MaxSwapFees is the worst-case total fees of the swap transactions of an order
+// of the lots, at the asset's MaxFeeRate.
+func (a *Asset) MaxSwapFees(lots, inputsSize uint64) (uint64, error) {
+	size, err := a.SwapTxSize(lots, inputsSize)
+	if err != nil {
+		return 0, err
+	}
+	return mul(size, a.MaxFeeRate)
+}
+
+// RequiredFunds is the funding needed for an order swapping value in lots,
+// which is the value plus the worst-case swap fees.
+func (a *Asset) RequiredFunds(value, lots, inputsSize uint64) (uint64, error) {
+	fees, err := a.MaxSwapFees(lots, inputsSize)
+	if err != nil {
+		return 0, err
+	}
+	return add(value, fees)
+}
+
+// CheckFunding checks that funds are enough to fund an order swapping value in
+// lots, returning an InsufficientFundsError if not.
+func (a *Asset) CheckFunding(funds, value, lots, inputsSize uint64) error {
+	req, err := a.RequiredFunds(value, lots, inputsSize)
+	if err != nil {
+		return err
+	}
+	if funds < req {
+		return NewError(InsufficientFundsError, fmt.Sprintf("%d %s atoms is less than the %d required for %d lots",
+			funds, a.Symbol, req, lots))
+	}
+	return nil
+}
+
+// RedeemFees estimates the fees of redeeming count contracts, each in its own
+// transaction, at the fee rate.
+func (a *Asset) RedeemFees(count, feeRate uint64) (uint64, error) {
+	size, err := mul(count, a.RedeemSize)
+	if err != nil {
+		return 0, err
+	}
+	return mul(size, feeRate)
+}
+
+// RefundFees estimates the fees of refunding count contracts, each in its own
+// transaction, at the fee rate.
+func (a *Asset) RefundFees(count, feeRate uint64) (uint64, error) {
+	size, err := mul(count, a.RefundSize)
+	if err != nil {
+		return 0, err
+	}
+	return mul(size, feeRate)
+}
+
+// CheckFeeRate checks a client-declared swap fee rate. It must be at least
+// minFeeRate, usually the current fee estimate, and no more than the asset's
+// MaxFeeRate, which is what orders are funded for.
+func (a *Asset) CheckFeeRate(feeRate, minFeeRate uint64) error {
+	switch {
+	case feeRate == 0:
+		return NewError(FeeRateError, "zero fee rate")
+	case feeRate < minFeeRate:
+		return NewError(FeeRateError, fmt.Sprintf("%s fee rate %d is below the minimum %d",
+			a.Symbol, feeRate, minFeeRate))
+	case feeRate > a.MaxFeeRate:
+		return NewError(FeeRateError, fmt.Sprintf("%s fee rate %d exceeds the maximum %d",
+			a.Symbol, feeRate, a.MaxFeeRate))
+	}
+	return nil
+}
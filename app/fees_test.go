@@ -0,0 +1,82 @@
+package app
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSwapFees(t *testing.T) {
+	btc, err := AssetBySymbol(Mainnet, "btc")
+	if err != nil {
+		t.Fatalf("btc not registered: %v", err)
+	}
+	for _, tt := range []struct {
+		name       string
+		lots       uint64
+		inputsSize uint64
+		size       uint64
+	}{
+		{"no lots", 0, 100, 0},
+		{"one lot", 1, 100, 84 + 100},
+		{"three lots", 3, 100, 84 + 100 + 2*153},
+	} {
+		size, err := btc.SwapTxSize(tt.lots, tt.inputsSize)
+		if err != nil {
+			t.Fatalf("%s: SwapTxSize error: %v", tt.name, err)
+		}
+		if size != tt.size {
+			t.Errorf("%s: size %d, want %d", tt.name, size, tt.size)
+		}
+		fees, _ := btc.MaxSwapFees(tt.lots, tt.inputsSize)
+		if fees != tt.size*btc.MaxFeeRate {
+			t.Errorf("%s: fees %d, want %d", tt.name, fees, tt.size*btc.MaxFeeRate)
+		}
+	}
+
+	req, err := btc.RequiredFunds(5e5, 5, 100)
+	if err != nil {
+		t.Fatalf("RequiredFunds error: %v", err)
+	}
+	if want := uint64(5e5 + (84+100+4*153)*100); req != want {
+		t.Fatalf("required funds %d, want %d", req, want)
+	}
+	if err = btc.CheckFunding(req, 5e5, 5, 100); err != nil {
+		t.Fatalf("sufficient funding rejected: %v", err)
+	}
+	if err = btc.CheckFunding(req-1, 5e5, 5, 100); !errors.Is(err, InsufficientFundsError) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if _, err = btc.RequiredFunds(math.MaxUint64, 1, 0); !errors.Is(err, AmountOverflowError) {
+		t.Fatalf("expected overflow, got %v", err)
+	}
+	if _, err = btc.MaxSwapFees(math.MaxUint64, 0); !errors.Is(err, AmountOverflowError) {
+		t.Fatalf("expected overflow, got %v", err)
+	}
+
+	if fees, _ := btc.RedeemFees(2, 10); fees != 2*143*10 {
+		t.Fatalf("wrong redeem fees %d", fees)
+	}
+	if fees, _ := btc.RefundFees(1, 10); fees != 135*10 {
+		t.Fatalf("wrong refund fees %d", fees)
+	}
+}
+
+func TestCheckFeeRate(t *testing.T) {
+	dcr, _ := AssetBySymbol(Mainnet, "dcr")
+	for _, tt := range []struct {
+		rate, min uint64
+		ok        bool
+	}{
+		{10, 1, true},
+		{1, 1, true},
+		{0, 0, false},
+		{1, 2, false},
+		{11, 1, false},
+	} {
+		err := dcr.CheckFeeRate(tt.rate, tt.min)
+		if (err == nil) != tt.ok || (err != nil && !errors.Is(err, FeeRateError)) {
+			t.Errorf("rate %d, min %d: unexpected error %v", tt.rate, tt.min, err)
+		}
+	}
+}
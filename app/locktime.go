@@ -0,0 +1,147 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	InvalidLockTimeError = ErrorKind("invalid locktime")
+
+	// MinLockTimeMargin is the least time by which the maker's locktime must
+	// exceed the taker's on mainnet. The margin gives the taker time to find
+	// the secret and redeem if the maker redeems just before the taker's
+	// contract expires. The test locktimes need only be ordered, so that
+	// swaps may be tested within minutes.
+	MinLockTimeMargin = time.Hour
+)
+
+// MarketLockTimes are the locktimes of a market's swap contracts. The maker
+// initiates, so its contract must be locked longer.
+type MarketLockTimes struct {
+	Taker time.Duration
+	Maker time.Duration
+}
+
+// Validate checks that the taker locktime is positive and the maker locktime
+// exceeds it by more than the margin.
+func (lt *MarketLockTimes) Validate(margin time.Duration) error {
+	if lt.Taker <= 0 {
+		return NewError(InvalidLockTimeError, fmt.Sprintf("taker locktime %v is not positive", lt.Taker))
+	}
+	if lt.Maker <= lt.Taker+margin {
+		return NewError(InvalidLockTimeError, fmt.Sprintf("maker locktime %v does not exceed taker locktime %v by more than %v",
+			lt.Maker, lt.Taker, margin))
+	}
+	return nil
+}
+
+type marketLockTimesJSON struct {
+	Taker uint64 `json:"taker"` // msec
+	Maker uint64 `json:"maker"` // msec
+}
+
+// MarshalJSON encodes the locktimes in milliseconds.
+func (lt MarketLockTimes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&marketLockTimesJSON{
+		Taker: uint64(lt.Taker / time.Millisecond),
+		Maker: uint64(lt.Maker / time.Millisecond),
+	})
+}
+
+// UnmarshalJSON decodes locktimes in milliseconds.
+func (lt *MarketLockTimes) UnmarshalJSON(b []byte) error {
+	var ltj marketLockTimesJSON
+	if err := json.Unmarshal(b, &ltj); err != nil {
+		return err
+	}
+	lt.Taker = time.Duration(ltj.Taker) * time.Millisecond
+	lt.Maker = time.Duration(ltj.Maker) * time.Millisecond
+	return nil
+}
+
+// LockTimeConfig is the policy from which market locktimes are derived.
+type LockTimeConfig struct {
+	// MinTaker is the shortest taker locktime.
+	MinTaker time.Duration `json:"minTaker"`
+	// ConfsFactor multiplies the expected time for both swap contracts to
+	// reach their required confirmations, giving the taker locktime if that
+	// exceeds MinTaker.
+	ConfsFactor uint32 `json:"confsFactor"`
+	// SafetyMargin is added to the taker locktime, with the time for both
+	// contracts to confirm, to give the maker locktime. It must be at least
+	// MinLockTimeMargin.
+	SafetyMargin time.Duration `json:"safetyMargin"`
+}
+
+// DefaultLockTimeConfig derives the default locktimes for markets of chains
+// that confirm swaps within a couple of hours.
+var DefaultLockTimeConfig = LockTimeConfig{
+	MinTaker:     defaultLockTimeTaker,
+	ConfsFactor:  4,
+	SafetyMargin: defaultlockTimeMaker - defaultLockTimeTaker,
+}
+
+// swapConfTime is the expected time for a swap contract in the asset to reach
+// its required confirmations.
+func swapConfTime(a *Asset) time.Duration {
+	return time.Duration(a.SwapConf) * time.Duration(a.BlockTime) * time.Millisecond
+}
+
+// DeriveLockTimes derives the locktimes of a market in the base and quote
+// assets from their block times and swap confirmations.
+func (cfg *LockTimeConfig) DeriveLockTimes(base, quote *Asset) (*MarketLockTimes, error) {
+	if cfg.SafetyMargin < MinLockTimeMargin {
+		return nil, NewError(InvalidLockTimeError, fmt.Sprintf("safety margin %v is less than %v",
+			cfg.SafetyMargin, MinLockTimeMargin))
+	}
+	for _, a := range []*Asset{base, quote} {
+		if a.BlockTime == 0 || a.SwapConf == 0 {
+			return nil, NewError(InvalidLockTimeError, fmt.Sprintf("%s has no block time or swap confirmations", a.Symbol))
+		}
+	}
+	confTime := swapConfTime(base) + swapConfTime(quote)
+	taker := confTime * time.Duration(cfg.ConfsFactor)
+	if taker < cfg.MinTaker {
+		taker = cfg.MinTaker
+	}
+	lt := &MarketLockTimes{
+		Taker: taker,
+		Maker: taker + cfg.SafetyMargin + confTime,
+	}
+	if err := lt.Validate(MinLockTimeMargin); err != nil {
+		return nil, err
+	}
+	return lt, nil
+}
+
+// SwapLockTimes returns the locktimes of swaps between the two assets on the
+// network. On mainnet they are derived from the assets with
+// DefaultLockTimeConfig. Test networks use the locktimes of LockTimeTaker and
+// LockTimeMaker for every pair, so that they may be shortened for testing.
+func SwapLockTimes(net Network, assetA, assetB uint32) (*MarketLockTimes, error) {
+	if net != Mainnet {
+		taker, err := LockTimeTaker(net)
+		if err != nil {
+			return nil, err
+		}
+		maker, err := LockTimeMaker(net)
+		if err != nil {
+			return nil, err
+		}
+		return &MarketLockTimes{Taker: taker, Maker: maker}, nil
+	}
+	a, err := AssetByID(net, assetA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := AssetByID(net, assetB)
+	if err != nil {
+		return nil, err
+	}
+	return DefaultLockTimeConfig.DeriveLockTimes(a, b)
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeriveLockTimes(t *testing.T) {
+	btc, _ := AssetBySymbol(Mainnet, "btc")
+	dcr, _ := AssetBySymbol(Mainnet, "dcr")
+	slow := &Asset{Symbol: "slow", SwapConf: 6, BlockTime: uint64(time.Hour / time.Millisecond)}
+
+	tests := []struct {
+		name         string
+		cfg          LockTimeConfig
+		base, quote  *Asset
+		taker, maker time.Duration
+		wantErr      bool
+	}{
+		{"default", DefaultLockTimeConfig, dcr, btc, 8 * time.Hour, 20*time.Hour + 30*time.Minute, false},
+		{"slow chains", DefaultLockTimeConfig, slow, slow, 48 * time.Hour, 72 * time.Hour, false},
+		{"small margin", LockTimeConfig{MinTaker: time.Hour, ConfsFactor: 2, SafetyMargin: time.Minute}, dcr, btc, 0, 0, true},
+		{"no block time", DefaultLockTimeConfig, dcr, &Asset{Symbol: "x", SwapConf: 1}, 0, 0, true},
+	}
+	for _, tt := range tests {
+		lt, err := tt.cfg.DeriveLockTimes(tt.base, tt.quote)
+		if tt.wantErr {
+			if !errors.Is(err, InvalidLockTimeError) {
+				t.Errorf("%s: expected invalid locktime error, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if lt.Taker != tt.taker || lt.Maker != tt.maker {
+			t.Errorf("%s: expected taker %v, maker %v, got %v, %v", tt.name, tt.taker, tt.maker, lt.Taker, lt.Maker)
+		}
+	}
+}
+
+func TestMarketLockTimes(t *testing.T) {
+	for _, lt := range []MarketLockTimes{
+		{Taker: 0, Maker: 10 * time.Hour},
+		{Taker: time.Hour, Maker: time.Hour + MinLockTimeMargin},
+		{Taker: 2 * time.Hour, Maker: time.Hour},
+	} {
+		if err := lt.Validate(MinLockTimeMargin); !errors.Is(err, InvalidLockTimeError) {
+			t.Errorf("%+v: expected invalid locktime error, got %v", lt, err)
+		}
+	}
+
+	mi, err := NewMarketInfo(Mainnet, 42, 0, 1e8, 10000, 1.5)
+	if err != nil {
+		t.Fatalf("NewMarketInfo error: %v", err)
+	}
+	// The dcr_btc locktimes are the pair's swap locktimes, derived from the
+	// default policy on mainnet.
+	if mi.LockTimes != nil {
+		t.Fatalf("locktimes set without being configured")
+	}
+	swapLockTimes, err := SwapLockTimes(Mainnet, 0, 42)
+	if err != nil {
+		t.Fatalf("SwapLockTimes error: %v", err)
+	}
+	lt, err := mi.MarketLockTimes(Mainnet)
+	if err != nil || lt != *swapLockTimes || lt.Taker != 8*time.Hour || lt.Maker != 20*time.Hour+30*time.Minute {
+		t.Fatalf("wrong market locktimes %+v, err = %v", lt, err)
+	}
+	// Test networks use the test locktimes.
+	if lt, err = mi.MarketLockTimes(Testnet); err != nil || lt.Taker != defaultLockTimeTaker || lt.Maker != defaultlockTimeMaker {
+		t.Fatalf("wrong testnet market locktimes %+v, err = %v", lt, err)
+	}
+	mi.LockTimes = &MarketLockTimes{Taker: 2 * time.Hour, Maker: 5 * time.Hour}
+	if err := mi.Validate(); err != nil {
+		t.Fatalf("valid locktimes failed validation: %v", err)
+	}
+	b, err := json.Marshal(mi)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	var mi2 MarketInfo
+	if err = json.Unmarshal(b, &mi2); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if lt, _ := mi2.MarketLockTimes(Testnet); *mi2.LockTimes != *mi.LockTimes || lt != *mi.LockTimes {
+		t.Fatalf("locktimes did not round trip: %s", b)
+	}
+	mi.LockTimes.Maker = 2*time.Hour + MinLockTimeMargin
+	if err = mi.Validate(); !errors.Is(err, InvalidMarketError) {
+		t.Fatalf("expected invalid market error, got %v", err)
+	}
+}
+
+func TestSetTestLockTimes(t *testing.T) {
+	defer SetTestLockTimes(defaultLockTimeTaker, defaultlockTimeMaker)
+	for _, lt := range []MarketLockTimes{
+		{Taker: 0, Maker: time.Minute},
+		{Taker: time.Minute, Maker: time.Minute},
+		{Taker: 2 * time.Minute, Maker: time.Minute},
+	} {
+		if err := SetTestLockTimes(lt.Taker, lt.Maker); !errors.Is(err, InvalidLockTimeError) {
+			t.Errorf("%+v: expected invalid locktime error, got %v", lt, err)
+		}
+	}
+	// Test locktimes need no margin, and are used by markets on test networks.
+	if err := SetTestLockTimes(30*time.Second, time.Minute); err != nil {
+		t.Fatalf("SetTestLockTimes error: %v", err)
+	}
+	if _, err := NewMarketInfo(Simnet, 42, 0, 1e8, 10000, 1.5); err != nil {
+		t.Fatalf("NewMarketInfo error with short test locktimes: %v", err)
+	}
+	if lt, err := SwapLockTimes(Simnet, 42, 0); err != nil || lt.Taker != 30*time.Second || lt.Maker != time.Minute {
+		t.Fatalf("wrong simnet swap locktimes %+v, err = %v", lt, err)
+	}
+	if err := SetTestLockTimes(time.Minute, 2*time.Hour); err != nil {
+		t.Fatalf("SetTestLockTimes error: %v", err)
+	}
+	taker, _ := LockTimeTaker(Testnet)
+	maker, _ := LockTimeMaker(Regtest)
+	if taker != time.Minute || maker != 2*time.Hour {
+		t.Fatalf("test locktimes not set")
+	}
+	if taker, _ = LockTimeTaker(Mainnet); taker != defaultLockTimeTaker {
+		t.Fatalf("mainnet locktime changed")
+	}
+	if err := TestLockTimeError(); err != nil {
+		t.Fatalf("unexpected test locktime error: %v", err)
+	}
+}
+
+func TestInvalidTestLockTimes(t *testing.T) {
+	// Invalid build-time test locktimes fail closed on test networks.
+	defer SetTestLockTimes(defaultLockTimeTaker, defaultlockTimeMaker)
+	testLockTime.Lock()
+	testLockTime.err = errors.New("invalid value for testLockTimeTaker")
+	testLockTime.Unlock()
+
+	if _, err := LockTimeTaker(Testnet); err == nil {
+		t.Fatalf("no taker locktime error")
+	}
+	if _, err := LockTimeMaker(Regtest); err == nil {
+		t.Fatalf("no maker locktime error")
+	}
+	if _, err := NewMarketInfo(Testnet, 42, 0, 1e8, 10000, 1.5); err == nil {
+		t.Fatalf("market created without test locktimes")
+	}
+	if _, err := (&MarketInfo{}).MarketLockTimes(Testnet); err == nil {
+		t.Fatalf("market locktimes without test locktimes")
+	}
+	if lt, err := LockTimeTaker(Mainnet); err != nil || lt != defaultLockTimeTaker {
+		t.Fatalf("mainnet locktime failed: %v", err)
+	}
+}
@@ -1,21 +1,50 @@
 package app
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+const (
+	InvalidMarketError = ErrorKind("invalid market")
+	MarketBuyError     = ErrorKind("market buy too small")
+
+	// MinEpochDuration and MaxEpochDuration bound a market's epoch duration,
+	// in milliseconds.
+	MinEpochDuration = 1000
+	MaxEpochDuration = 3600 * 1000
+)
+
 // MarketInfo specifies a market that the Archiver must support.
 type MarketInfo struct {
-	Name                   string
-	Base                   uint32
-	Quote                  uint32
-	LotSize                uint64
-	EpochDuration          uint64 // msec
-	MarketBuyBuffer        float64
-	MaxUserCancelsPerEpoch uint32
-	BookedLotLimit         uint32
+	Name                   string  `json:"name"`
+	Base                   uint32  `json:"base"`
+	Quote                  uint32  `json:"quote"`
+	LotSize                uint64  `json:"lotSize"`
+	EpochDuration          uint64  `json:"epochDuration"` // msec
+	MarketBuyBuffer        float64 `json:"marketBuyBuffer"`
+	MaxUserCancelsPerEpoch uint32  `json:"maxUserCancelsPerEpoch"`
+	BookedLotLimit         uint32  `json:"bookedLotLimit"`
+	// LockTimes are the market's swap locktimes. If nil, the locktimes of
+	// SwapLockTimes for the market's assets are used.
+	LockTimes *MarketLockTimes `json:"lockTimes,omitempty"`
+}
+
+// MarketLockTimes returns the market's swap locktimes on the network.
+func (mi *MarketInfo) MarketLockTimes(net Network) (MarketLockTimes, error) {
+	if mi.LockTimes != nil {
+		return *mi.LockTimes, nil
+	}
+	lt, err := SwapLockTimes(net, mi.Base, mi.Quote)
+	if err != nil {
+		return MarketLockTimes{}, err
+	}
+	return *lt, nil
 }
 
 func marketName(base, quote string) string {
@@ -43,13 +72,15 @@ func MarketName(base, quote uint32) (string, error) {
 
 // NewMarketInfo creates a new market configuration (MarketInfo) from the given
 // base and quote asset indexes, order lot size, and epoch duration in
-// milliseconds. See also MarketName.
-func NewMarketInfo(base, quote uint32, lotSize, epochDuration uint64, marketBuyBuffer float64) (*MarketInfo, error) {
+// milliseconds. The market uses the swap locktimes of its assets on the
+// network, the same as swaps made outside a market, and is validated. See also
+// MarketName.
+func NewMarketInfo(net Network, base, quote uint32, lotSize, epochDuration uint64, marketBuyBuffer float64) (*MarketInfo, error) {
 	name, err := MarketName(base, quote)
 	if err != nil {
 		return nil, err
 	}
-	return &MarketInfo{
+	mi := &MarketInfo{
 		Name:                   name,
 		Base:                   base,
 		Quote:                  quote,
@@ -58,5 +89,149 @@ func NewMarketInfo(base, quote uint32, lotSize, epochDuration uint64, marketBuyB
 		MarketBuyBuffer:        marketBuyBuffer,
 		MaxUserCancelsPerEpoch: math.MaxUint32,
 		BookedLotLimit:         math.MaxUint32,
-	}, nil
+	}
+	if err = mi.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err = mi.MarketLockTimes(net); err != nil {
+		return nil, err
+	}
+	return mi, nil
+}
+
+// ParseMarketName returns the base and quote asset IDs of a market name created
+// by MarketName, e.g. "dcr_btc". Duplicate ticker symbols must be given with
+// their bracketed suffix, e.g. "cpc[capricoin]_btc".
+func ParseMarketName(name string) (base, quote uint32, err error) {
+	symbols := strings.Split(strings.ToLower(name), "_")
+	if len(symbols) != 2 || symbols[0] == "" || symbols[1] == "" {
+		return 0, 0, NewError(InvalidMarketError, fmt.Sprintf("market name %q is not of the form base_quote", name))
+	}
+	ids := make([]uint32, 2)
+	for i, symbol := range symbols {
+		id, found := BipSymbolID(symbol)
+		if !found {
+			if dups := bracketedSymbols(symbol); len(dups) > 0 {
+				return 0, 0, NewError(UnknownAssetError, fmt.Sprintf("symbol %q is ambiguous, use one of %s",
+					symbol, strings.Join(dups, ", ")))
+			}
+			return 0, 0, NewError(UnknownAssetError, fmt.Sprintf("unknown symbol %q in market %q", symbol, name))
+		}
+		ids[i] = id
+	}
+	return ids[0], ids[1], nil
+}
+
+// bracketedSymbols returns the bracketed forms of a duplicate ticker symbol.
+func bracketedSymbols(symbol string) []string {
+	var dups []string
+	for _, sym := range bipIDs {
+		if strings.HasPrefix(sym, symbol+"[") {
+			dups = append(dups, sym)
+		}
+	}
+	sort.Strings(dups)
+	return dups
+}
+
+// Validate checks that the market's assets are known and distinct, that its
+// name matches them, and that its parameters are usable.
+func (mi *MarketInfo) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return NewError(InvalidMarketError, fmt.Sprintf("%s: ", mi.Name)+fmt.Sprintf(format, args...))
+	}
+	if mi.Base == mi.Quote {
+		return invalid("base and quote are both asset %d", mi.Base)
+	}
+	name, err := MarketName(mi.Base, mi.Quote)
+	if err != nil {
+		return invalid("%v", err)
+	}
+	switch {
+	case mi.Name != name:
+		return invalid("name does not match the assets, expected %q", name)
+	case mi.LotSize == 0:
+		return invalid("zero lot size")
+	case mi.EpochDuration < MinEpochDuration || mi.EpochDuration > MaxEpochDuration:
+		return invalid("epoch duration %d ms is not between %d and %d ms", mi.EpochDuration,
+			MinEpochDuration, MaxEpochDuration)
+	case !(mi.MarketBuyBuffer > 1):
+		return invalid("market buy buffer %v is not greater than 1", mi.MarketBuyBuffer)
+	}
+	if mi.LockTimes != nil {
+		if err := mi.LockTimes.Validate(MinLockTimeMargin); err != nil {
+			return invalid("%v", err)
+		}
+	}
+	return nil
+}
+
+// MinMarketBuy is the smallest quantity of quote asset atoms a market buy order
+// may have, which is MarketBuyBuffer times the cost of one lot at the best sell
+// rate on the book. A market buy cannot be priced without a sell order on the
+// book, so a zero rate is a MarketBuyError.
+func (mi *MarketInfo) MinMarketBuy(bestSellRate uint64) (uint64, error) {
+	if bestSellRate == 0 {
+		return 0, NewError(MarketBuyError, fmt.Sprintf("%s: no sell orders on the book", mi.Name))
+	}
+	lotCost, err := BaseToQuote(bestSellRate, mi.LotSize)
+	if err != nil {
+		return 0, err
+	}
+	// The buffer is taken as the decimal it was configured as, e.g. exactly
+	// 11/10 for 1.1, and the buffered cost is rounded up to a whole atom.
+	buffer, ok := new(big.Rat).SetString(strconv.FormatFloat(mi.MarketBuyBuffer, 'g', -1, 64))
+	if !ok {
+		return 0, NewError(InvalidMarketError, fmt.Sprintf("%s: market buy buffer %v", mi.Name, mi.MarketBuyBuffer))
+	}
+	min, rem := new(big.Int).QuoRem(new(big.Int).Mul(new(big.Int).SetUint64(lotCost), buffer.Num()),
+		buffer.Denom(), new(big.Int))
+	if rem.Sign() != 0 {
+		min.Add(min, big.NewInt(1))
+	}
+	if !min.IsUint64() {
+		return 0, NewError(AmountOverflowError, fmt.Sprintf("%s: buffered lot cost at rate %d",
+			mi.Name, bestSellRate))
+	}
+	return min.Uint64(), nil
+}
+
+// CheckMarketBuy checks that a market buy of the quantity of quote asset atoms
+// covers MinMarketBuy at the best sell rate, returning a MarketBuyError if not.
+func (mi *MarketInfo) CheckMarketBuy(quantity, bestSellRate uint64) error {
+	min, err := mi.MinMarketBuy(bestSellRate)
+	if err != nil {
+		return err
+	}
+	if quantity < min {
+		return NewError(MarketBuyError, fmt.Sprintf("%s: quantity %d is less than %v times the lot cost at rate %d (%d)",
+			mi.Name, quantity, mi.MarketBuyBuffer, bestSellRate, min))
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes and validates a MarketInfo. The name may be omitted,
+// and is then derived from the assets. The cancel and booked lot limits
+// default to unlimited, as with NewMarketInfo.
+func (mi *MarketInfo) UnmarshalJSON(b []byte) error {
+	type marketInfo MarketInfo
+	m := marketInfo{
+		MaxUserCancelsPerEpoch: math.MaxUint32,
+		BookedLotLimit:         math.MaxUint32,
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	if m.Name == "" {
+		name, err := MarketName(m.Base, m.Quote)
+		if err != nil {
+			return NewError(InvalidMarketError, err.Error())
+		}
+		m.Name = name
+	}
+	if err := (*MarketInfo)(&m).Validate(); err != nil {
+		return err
+	}
+	*mi = MarketInfo(m)
+	return nil
 }
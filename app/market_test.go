@@ -0,0 +1,172 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseMarketName(t *testing.T) {
+	tests := []struct {
+		name        string
+		base, quote uint32
+		wantErr     error
+	}{
+		{name: "dcr_btc", base: 42, quote: 0},
+		{name: "BTC_LTC", base: 0, quote: 2},
+		{name: "cpc[capricoin]_btc", base: 289, quote: 0},
+		{name: "btc_cpc[cpcchain]", base: 0, quote: 337},
+		{name: "cpc_btc", wantErr: UnknownAssetError},
+		{name: "dcr_notacoin", wantErr: UnknownAssetError},
+		{name: "dcrbtc", wantErr: InvalidMarketError},
+		{name: "dcr_btc_ltc", wantErr: InvalidMarketError},
+		{name: "_btc", wantErr: InvalidMarketError},
+	}
+	for _, tt := range tests {
+		base, quote, err := ParseMarketName(tt.name)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if base != tt.base || quote != tt.quote {
+			t.Errorf("%s: expected %d, %d, got %d, %d", tt.name, tt.base, tt.quote, base, quote)
+		}
+		name, _ := MarketName(base, quote)
+		if b, q, _ := ParseMarketName(name); b != base || q != quote {
+			t.Errorf("%s: %s did not round trip", tt.name, name)
+		}
+	}
+}
+
+func TestMarketInfoValidate(t *testing.T) {
+	mi, err := NewMarketInfo(Mainnet, 42, 0, 1e8, 10000, 1.5)
+	if err != nil {
+		t.Fatalf("NewMarketInfo error: %v", err)
+	}
+	if err = mi.Validate(); err != nil {
+		t.Fatalf("valid market failed validation: %v", err)
+	}
+	bad := []func(mi *MarketInfo){
+		func(mi *MarketInfo) { mi.Quote = mi.Base },
+		func(mi *MarketInfo) { mi.Base = 1 << 30 },
+		func(mi *MarketInfo) { mi.Name = "btc_dcr" },
+		func(mi *MarketInfo) { mi.LotSize = 0 },
+		func(mi *MarketInfo) { mi.EpochDuration = MinEpochDuration - 1 },
+		func(mi *MarketInfo) { mi.EpochDuration = MaxEpochDuration + 1 },
+		func(mi *MarketInfo) { mi.MarketBuyBuffer = 1 },
+		func(mi *MarketInfo) { mi.MarketBuyBuffer = math.NaN() },
+	}
+	for i, mod := range bad {
+		m := *mi
+		mod(&m)
+		if err := m.Validate(); !errors.Is(err, InvalidMarketError) {
+			t.Errorf("bad market %d: expected invalid market error, got %v", i, err)
+		}
+	}
+}
+
+func TestMarketInfoJSON(t *testing.T) {
+	mi, _ := NewMarketInfo(Mainnet, 42, 0, 1e8, 10000, 1.5)
+	b, err := json.Marshal(mi)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	exp := `{"name":"dcr_btc","base":42,"quote":0,"lotSize":100000000,"epochDuration":10000,` +
+		`"marketBuyBuffer":1.5,"maxUserCancelsPerEpoch":4294967295,"bookedLotLimit":4294967295}`
+	if string(b) != exp {
+		t.Fatalf("unexpected JSON %s", b)
+	}
+	var mi2 MarketInfo
+	if err = json.Unmarshal(b, &mi2); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(mi, &mi2) {
+		t.Fatalf("round trip mismatch: %+v != %+v", mi, mi2)
+	}
+
+	// The name, limits and locktimes are optional.
+	var mi3 MarketInfo
+	err = json.Unmarshal([]byte(`{"base":42,"quote":0,"lotSize":100000000,"epochDuration":10000,"marketBuyBuffer":1.5}`), &mi3)
+	if err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if mi3.LockTimes != nil {
+		t.Fatalf("locktimes set without being configured")
+	}
+	if !reflect.DeepEqual(mi, &mi3) {
+		t.Fatalf("defaults mismatch: %+v != %+v", mi, mi3)
+	}
+
+	var mi4 MarketInfo
+	err = json.Unmarshal([]byte(`{"base":42,"quote":0,"lotSize":0,"epochDuration":10000,"marketBuyBuffer":1.5}`), &mi4)
+	if !errors.Is(err, InvalidMarketError) {
+		t.Fatalf("expected invalid market error, got %v", err)
+	}
+}
+
+func TestMarketBuy(t *testing.T) {
+	mi, _ := NewMarketInfo(Mainnet, 42, 0, 1e8, 10000, 1.5)
+	// One DCR at 0.01 BTC costs 1e6 BTC atoms, so the minimum is 1.5e6.
+	const rate = 1e6
+	min, err := mi.MinMarketBuy(rate)
+	if err != nil {
+		t.Fatalf("MinMarketBuy error: %v", err)
+	}
+	if min != 1.5e6 {
+		t.Fatalf("wrong minimum %d", min)
+	}
+	if err = mi.CheckMarketBuy(1.5e6, rate); err != nil {
+		t.Fatalf("buffered buy rejected: %v", err)
+	}
+	for _, tt := range []struct {
+		name      string
+		quantity  uint64
+		rate      uint64
+		errorKind error
+	}{
+		{"one lot", 1e6, rate, MarketBuyError},
+		{"short", 1.5e6 - 1, rate, MarketBuyError},
+		{"empty book", 1e12, 0, MarketBuyError},
+		{"overflow", 1e12, math.MaxUint64, AmountOverflowError},
+	} {
+		if err = mi.CheckMarketBuy(tt.quantity, tt.rate); !errors.Is(err, tt.errorKind) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.errorKind, err)
+		}
+	}
+}
+
+func TestMinMarketBuyExact(t *testing.T) {
+	mi, _ := NewMarketInfo(Mainnet, 42, 0, 1e8, 10000, 1.5)
+	for _, tt := range []struct {
+		name   string
+		buffer float64
+		rate   uint64
+		min    uint64
+	}{
+		// A float64 cannot hold a lot cost this large exactly.
+		{"large lot cost", 1.5, 1<<60 + 1, 3<<59 + 2},
+		{"large lot cost exact", 1.5, 1<<60 + 2, 3<<59 + 3},
+		// 1.1 is not exact as a float64, but is taken as 11/10.
+		{"decimal buffer", 1.1, 1e6, 1.1e6},
+		{"rounded up", 1.1, 1e6 + 1, 1100002},
+		{"max", 1.25, 4 * (math.MaxUint64 / 5), 5 * (math.MaxUint64 / 5)},
+	} {
+		mi.MarketBuyBuffer = tt.buffer
+		min, err := mi.MinMarketBuy(tt.rate)
+		if err != nil {
+			t.Errorf("%s: MinMarketBuy error: %v", tt.name, err)
+			continue
+		}
+		if min != tt.min {
+			t.Errorf("%s: got %d, want %d", tt.name, min, tt.min)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+// Package msgjson defines the JSON messages exchanged by clients and the
+// server, so that both share a single schema.
+package msgjson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"decred.org/dcrdex/dex"
+	"github.com/skynet0590/inswap/app"
+)
+
+// Routes are the names of the requests and notifications.
+const (
+	// LimitRoute is the client request to submit a limit order.
+	LimitRoute = "limit"
+	// InstantRoute is the client request to submit an instant order.
+	InstantRoute = "instant"
+	// CancelRoute is the client request to submit a cancel order.
+	CancelRoute = "cancel"
+	// MatchRoute is the server request notifying a client of its matches.
+	MatchRoute = "match"
+	// InitRoute is the client request reporting a swap contract it sent.
+	InitRoute = "init"
+	// AuditRoute is the server request asking a client to audit the
+	// counterparty's contract.
+	AuditRoute = "audit"
+	// RedeemRoute is the client request reporting a redemption it sent.
+	RedeemRoute = "redeem"
+	// PreimageRoute is the server request for an order's preimage.
+	PreimageRoute = "preimage"
+	// EpochReportRoute is the server notification summarizing a closed epoch.
+	EpochReportRoute = "epoch_report"
+)
+
+// MessageType indicates the type of message.
+type MessageType uint8
+
+// The different MessageType values.
+const (
+	InvalidMessageType MessageType = iota
+	Request
+	Response
+	Notification
+)
+
+// String returns a string representation of the MessageType.
+func (mt MessageType) String() string {
+	switch mt {
+	case Request:
+		return "request"
+	case Response:
+		return "response"
+	case Notification:
+		return "notification"
+	default:
+		return "unknown"
+	}
+}
+
+// Message is the envelope of every request, response and notification.
+// Responses have the ID of their request and no route.
+type Message struct {
+	Type    MessageType     `json:"type"`
+	Route   string          `json:"route,omitempty"`
+	ID      uint64          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// ResponsePayload is the payload of a response, with either a result or an
+// error.
+type ResponsePayload struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *app.RPCError   `json:"error,omitempty"`
+}
+
+// NewRequest creates a request message with the payload.
+func NewRequest(id uint64, route string, payload interface{}) (*Message, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("request id cannot be zero")
+	}
+	if route == "" {
+		return nil, fmt.Errorf("request route cannot be empty")
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{Type: Request, Route: route, ID: id, Payload: b}, nil
+}
+
+// NewNotification creates a notification message with the payload.
+func NewNotification(route string, payload interface{}) (*Message, error) {
+	if route == "" {
+		return nil, fmt.Errorf("notification route cannot be empty")
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{Type: Notification, Route: route, Payload: b}, nil
+}
+
+// NewResponse creates a response to the request with the ID, with either a
+// result or an error. Errors should be created with app.NewRPCError, so that
+// only client-safe details are sent.
+func NewResponse(id uint64, result interface{}, rpcErr *app.RPCError) (*Message, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("response id cannot be zero")
+	}
+	payload := &ResponsePayload{Error: rpcErr}
+	if rpcErr == nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		payload.Result = b
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{Type: Response, ID: id, Payload: b}, nil
+}
+
+// Unmarshal decodes the payload of a request or notification.
+func (m *Message) Unmarshal(payload interface{}) error {
+	return json.Unmarshal(m.Payload, payload)
+}
+
+// Response decodes the payload of a response.
+func (m *Message) Response() (*ResponsePayload, error) {
+	if m.Type != Response {
+		return nil, fmt.Errorf("%s message is not a response", m.Type)
+	}
+	resp := new(ResponsePayload)
+	if err := json.Unmarshal(m.Payload, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Signable is a payload that is signed by its sender. Serialize gives the bytes
+// that are signed, or an error if the payload cannot be serialized.
+type Signable interface {
+	Serialize() ([]byte, error)
+	SetSig([]byte)
+	SigBytes() []byte
+}
+
+// Signature is embedded in signable payloads.
+type Signature struct {
+	Sig dex.Bytes `json:"sig"`
+}
+
+// SetSig sets the signature.
+func (s *Signature) SetSig(b []byte) {
+	s.Sig = b
+}
+
+// SigBytes returns the signature.
+func (s *Signature) SigBytes() []byte {
+	return s.Sig
+}
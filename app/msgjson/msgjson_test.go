@@ -0,0 +1,194 @@
+package msgjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"github.com/skynet0590/inswap/app"
+	"github.com/skynet0590/inswap/app/order"
+)
+
+func testPrefix(ot order.OrderType) order.Prefix {
+	return order.Prefix{
+		AccountID:  [32]byte{1},
+		BaseAsset:  42,
+		QuoteAsset: 0,
+		OrderType:  ot,
+		ClientTime: time.Unix(1600000000, 123e6),
+		ServerTime: time.Unix(1600000001, 0),
+		Commit:     order.Commitment{2},
+	}
+}
+
+func testTrade(sell bool) order.Trade {
+	return order.Trade{
+		Coins:    []order.CoinID{make([]byte, 36), {1, 2, 3}},
+		Sell:     sell,
+		Quantity: 3e8,
+		Address:  "DsTestAddress",
+	}
+}
+
+// roundTrip sends the payload through JSON into out.
+func roundTrip(t *testing.T, in, out interface{}) {
+	t.Helper()
+	msg, err := NewRequest(1, LimitRoute, in)
+	if err != nil {
+		t.Fatalf("NewRequest error: %v", err)
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	msg = new(Message)
+	if err = json.Unmarshal(b, msg); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if err = msg.Unmarshal(out); err != nil {
+		t.Fatalf("payload unmarshal error: %v", err)
+	}
+}
+
+func TestOrderConversions(t *testing.T) {
+	lo := &order.LimitOrder{P: testPrefix(order.LimitOrderType), T: testTrade(true), Rate: 1e6, Force: order.StandingTiF}
+	var loMsg LimitOrder
+	roundTrip(t, NewLimitOrder(lo), &loMsg)
+	lo2, err := loMsg.Order()
+	if err != nil {
+		t.Fatalf("limit order conversion error: %v", err)
+	}
+	loB, err := loMsg.Serialize()
+	if err != nil {
+		t.Fatalf("limit order serialize error: %v", err)
+	}
+	if lo2.ID() != lo.ID() || !bytes.Equal(loB, lo.Serialize()) {
+		t.Fatalf("limit order changed in conversion")
+	}
+
+	// An order the server has not stamped has no server time.
+	unstamped := &order.LimitOrder{P: testPrefix(order.LimitOrderType), T: testTrade(true), Rate: 1e6}
+	unstamped.ServerTime = time.Time{}
+	var unstampedMsg LimitOrder
+	roundTrip(t, NewLimitOrder(unstamped), &unstampedMsg)
+	if unstampedMsg.ServerTime != 0 {
+		t.Fatalf("unstamped order has server time %d", unstampedMsg.ServerTime)
+	}
+	lo3, err := unstampedMsg.Order()
+	if err != nil {
+		t.Fatalf("unstamped limit order conversion error: %v", err)
+	}
+	if !lo3.ServerTime.IsZero() {
+		t.Fatalf("unstamped order converted with server time %v", lo3.ServerTime)
+	}
+
+	mo := &order.InstantOrder{P: testPrefix(order.InstantOrderType), T: testTrade(false)}
+	var moMsg InstantOrder
+	roundTrip(t, NewInstantOrder(mo), &moMsg)
+	mo2, err := moMsg.Order()
+	if err != nil {
+		t.Fatalf("instant order conversion error: %v", err)
+	}
+	if mo2.ID() != mo.ID() || mo2.Sell {
+		t.Fatalf("instant order changed in conversion")
+	}
+
+	co := &order.CancelOrder{P: testPrefix(order.CancelOrderType), TargetOrderID: lo.ID()}
+	var coMsg CancelOrder
+	roundTrip(t, NewCancelOrder(co), &coMsg)
+	co2, err := coMsg.Order()
+	if err != nil {
+		t.Fatalf("cancel order conversion error: %v", err)
+	}
+	if co2.ID() != co.ID() {
+		t.Fatalf("cancel order changed in conversion")
+	}
+
+	for _, bad := range []func(lo *LimitOrder){
+		func(lo *LimitOrder) { lo.OrderType = uint8(order.CancelOrderType) },
+		func(lo *LimitOrder) { lo.AccountID = lo.AccountID[1:] },
+		func(lo *LimitOrder) { lo.Commit = nil },
+		func(lo *LimitOrder) { lo.Side = 3 },
+		func(lo *LimitOrder) { lo.TiF = 0 },
+	} {
+		msg := NewLimitOrder(lo)
+		bad(msg)
+		if _, err = msg.Order(); err == nil {
+			t.Errorf("bad limit order converted")
+		}
+		if b, err := msg.Serialize(); err == nil || b != nil {
+			t.Errorf("bad limit order serialized")
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := &order.Match{
+		Taker:      order.OrderID{1},
+		Maker:      order.OrderID{2},
+		Quantity:   1e8,
+		Rate:       1e6,
+		ServerTime: time.Unix(1600000002, 0),
+	}
+	taker := NewMatch(m, true, "DsMakerAddress")
+	maker := NewMatch(m, false, "DsTakerAddress")
+	mid := m.ID()
+	if taker.Side != Taker || maker.Side != Maker || !bytes.Equal(taker.MatchID, mid[:]) ||
+		!bytes.Equal(taker.OrderID, m.Taker[:]) || !bytes.Equal(maker.OrderID, m.Maker[:]) {
+		t.Fatalf("wrong match payloads")
+	}
+	takerB, _ := taker.Serialize()
+	makerB, _ := maker.Serialize()
+	if bytes.Equal(takerB, makerB) {
+		t.Fatalf("taker and maker payloads serialize the same")
+	}
+	var s Signable = taker
+	s.SetSig([]byte{5})
+	var taker2 Match
+	roundTrip(t, taker, &taker2)
+	taker2B, _ := taker2.Serialize()
+	if !bytes.Equal(taker2.SigBytes(), []byte{5}) || !bytes.Equal(taker2B, takerB) {
+		t.Fatalf("match changed in round trip")
+	}
+}
+
+func TestResponse(t *testing.T) {
+	msg, err := NewResponse(3, &OrderResult{OrderID: dex.Bytes{1}}, nil)
+	if err != nil {
+		t.Fatalf("NewResponse error: %v", err)
+	}
+	resp, err := msg.Response()
+	if err != nil || resp.Error != nil {
+		t.Fatalf("unexpected response %+v, err = %v", resp, err)
+	}
+	var result OrderResult
+	if err = json.Unmarshal(resp.Result, &result); err != nil || !bytes.Equal(result.OrderID, dex.Bytes{1}) {
+		t.Fatalf("wrong result %+v, err = %v", result, err)
+	}
+
+	msg, _ = NewResponse(4, nil, app.NewRPCError(app.NewCodedError(app.CodeUnknownMarket, "")))
+	b, _ := json.Marshal(msg)
+	if string(b) != `{"type":2,"id":4,"payload":{"error":{"code":300,"message":"unknown market"}}}` {
+		t.Fatalf("unexpected error response %s", b)
+	}
+
+	if _, err = NewRequest(0, LimitRoute, nil); err == nil {
+		t.Fatalf("zero request ID allowed")
+	}
+	notification, _ := NewNotification(EpochReportRoute, &EpochReport{MarketID: "dcr_btc"})
+	if _, err = notification.Response(); err == nil {
+		t.Fatalf("notification decoded as a response")
+	}
+}
+
+func TestPreimageResponse(t *testing.T) {
+	if _, err := (&PreimageResponse{Preimage: dex.Bytes{1}}).OrderPreimage(); err == nil {
+		t.Fatalf("short preimage accepted")
+	}
+	pi, err := (&PreimageResponse{Preimage: make(dex.Bytes, order.PreimageSize)}).OrderPreimage()
+	if err != nil || !pi.IsZero() {
+		t.Fatalf("preimage conversion failed: %v", err)
+	}
+}
@@ -0,0 +1,432 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package msgjson
+
+import (
+	"fmt"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"github.com/skynet0590/inswap/app/encode"
+	"github.com/skynet0590/inswap/app/order"
+	"github.com/skynet0590/inswap/server/account"
+)
+
+// Order sides and times in force on the wire.
+const (
+	BuyOrderNum       = 1
+	SellOrderNum      = 2
+	StandingOrderNum  = 1
+	ImmediateOrderNum = 2
+)
+
+// uint64Bytes is used to serialize signable payloads.
+var uint64Bytes = encode.Uint64Bytes
+
+// Prefix is the part of an order payload common to all orders. ServerTime is
+// zero until the server stamps the order.
+type Prefix struct {
+	Signature
+	AccountID  dex.Bytes `json:"accountid"`
+	Base       uint32    `json:"base"`
+	Quote      uint32    `json:"quote"`
+	OrderType  uint8     `json:"ordertype"`
+	ClientTime uint64    `json:"tclient"`
+	ServerTime uint64    `json:"tserver"`
+	Commit     dex.Bytes `json:"com"`
+}
+
+// Coin is a funding coin, with the public keys and signatures proving its
+// ownership and, for scripts, the redeem script.
+type Coin struct {
+	ID      dex.Bytes   `json:"coinid"`
+	PubKeys []dex.Bytes `json:"pubkeys"`
+	Sigs    []dex.Bytes `json:"sigs"`
+	Redeem  dex.Bytes   `json:"redeem"`
+}
+
+// Trade is the part of an order payload common to limit and instant orders.
+type Trade struct {
+	Side     uint8   `json:"side"`
+	Quantity uint64  `json:"ordersize"`
+	Coins    []*Coin `json:"coins"`
+	Address  string  `json:"address"`
+}
+
+// LimitOrder is the payload of a LimitRoute request.
+type LimitOrder struct {
+	Prefix
+	Trade
+	Rate uint64 `json:"rate"`
+	TiF  uint8  `json:"timeinforce"`
+}
+
+// InstantOrder is the payload of an InstantRoute request.
+type InstantOrder struct {
+	Prefix
+	Trade
+}
+
+// CancelOrder is the payload of a CancelRoute request.
+type CancelOrder struct {
+	Prefix
+	TargetID dex.Bytes `json:"targetid"`
+}
+
+// OrderResult is the result of an order submission, with the server's
+// signature of the order, which is stamped with the server time.
+type OrderResult struct {
+	Sig        dex.Bytes `json:"sig"`
+	OrderID    dex.Bytes `json:"orderid"`
+	ServerTime uint64    `json:"tserver"`
+}
+
+func newPrefix(p *order.Prefix) Prefix {
+	var serverTime uint64
+	if !p.ServerTime.IsZero() {
+		serverTime = encode.UnixMilliU(p.ServerTime)
+	}
+	return Prefix{
+		AccountID:  p.AccountID[:],
+		Base:       p.BaseAsset,
+		Quote:      p.QuoteAsset,
+		OrderType:  uint8(p.OrderType),
+		ClientTime: encode.UnixMilliU(p.ClientTime),
+		ServerTime: serverTime,
+		Commit:     p.Commit[:],
+	}
+}
+
+func (p *Prefix) order(ot order.OrderType) (*order.Prefix, error) {
+	if order.OrderType(p.OrderType) != ot {
+		return nil, fmt.Errorf("order type %d, expected %s", p.OrderType, ot)
+	}
+	if len(p.AccountID) != account.HashSize {
+		return nil, fmt.Errorf("account ID is %d bytes, expected %d", len(p.AccountID), account.HashSize)
+	}
+	if len(p.Commit) != order.CommitmentSize {
+		return nil, fmt.Errorf("commitment is %d bytes, expected %d", len(p.Commit), order.CommitmentSize)
+	}
+	// A zero server time is an order the server has not stamped yet.
+	var serverTime time.Time
+	if p.ServerTime != 0 {
+		serverTime = encode.UnixTimeMilli(int64(p.ServerTime))
+	}
+	op := &order.Prefix{
+		BaseAsset:  p.Base,
+		QuoteAsset: p.Quote,
+		OrderType:  ot,
+		ClientTime: encode.UnixTimeMilli(int64(p.ClientTime)),
+		ServerTime: serverTime,
+	}
+	copy(op.AccountID[:], p.AccountID)
+	copy(op.Commit[:], p.Commit)
+	return op, nil
+}
+
+func newTrade(t *order.Trade) Trade {
+	side := uint8(BuyOrderNum)
+	if t.Sell {
+		side = SellOrderNum
+	}
+	coins := make([]*Coin, 0, len(t.Coins))
+	for _, coinID := range t.Coins {
+		coins = append(coins, &Coin{ID: dex.Bytes(coinID)})
+	}
+	return Trade{
+		Side:     side,
+		Quantity: t.Quantity,
+		Coins:    coins,
+		Address:  t.Address,
+	}
+}
+
+// order converts the trade payload into ot.
+func (t *Trade) order(ot *order.Trade) error {
+	switch t.Side {
+	case BuyOrderNum:
+	case SellOrderNum:
+		ot.Sell = true
+	default:
+		return fmt.Errorf("unknown order side %d", t.Side)
+	}
+	ot.Quantity = t.Quantity
+	ot.Address = t.Address
+	ot.Coins = make([]order.CoinID, 0, len(t.Coins))
+	for _, coin := range t.Coins {
+		ot.Coins = append(ot.Coins, order.CoinID(coin.ID))
+	}
+	return nil
+}
+
+// NewLimitOrder creates the payload of a limit order.
+func NewLimitOrder(lo *order.LimitOrder) *LimitOrder {
+	tif := uint8(ImmediateOrderNum)
+	if lo.Force == order.StandingTiF {
+		tif = StandingOrderNum
+	}
+	return &LimitOrder{
+		Prefix: newPrefix(&lo.P),
+		Trade:  newTrade(&lo.T),
+		Rate:   lo.Rate,
+		TiF:    tif,
+	}
+}
+
+// Order converts the payload to a limit order.
+func (lo *LimitOrder) Order() (*order.LimitOrder, error) {
+	p, err := lo.Prefix.order(order.LimitOrderType)
+	if err != nil {
+		return nil, err
+	}
+	o := &order.LimitOrder{P: *p, Rate: lo.Rate}
+	if err = lo.Trade.order(&o.T); err != nil {
+		return nil, err
+	}
+	switch lo.TiF {
+	case StandingOrderNum:
+		o.Force = order.StandingTiF
+	case ImmediateOrderNum:
+		o.Force = order.ImmediateTiF
+	default:
+		return nil, fmt.Errorf("unknown time in force %d", lo.TiF)
+	}
+	return o, nil
+}
+
+// Serialize gives the bytes the client signs, the order's serialization. It
+// is an error if the payload is not a valid order.
+func (lo *LimitOrder) Serialize() ([]byte, error) {
+	o, err := lo.Order()
+	if err != nil {
+		return nil, err
+	}
+	return o.Serialize(), nil
+}
+
+// NewInstantOrder creates the payload of an instant order.
+func NewInstantOrder(mo *order.InstantOrder) *InstantOrder {
+	return &InstantOrder{
+		Prefix: newPrefix(&mo.P),
+		Trade:  newTrade(&mo.T),
+	}
+}
+
+// Order converts the payload to an instant order.
+func (mo *InstantOrder) Order() (*order.InstantOrder, error) {
+	p, err := mo.Prefix.order(order.InstantOrderType)
+	if err != nil {
+		return nil, err
+	}
+	o := &order.InstantOrder{P: *p}
+	if err = mo.Trade.order(&o.T); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Serialize gives the bytes the client signs, the order's serialization. It
+// is an error if the payload is not a valid order.
+func (mo *InstantOrder) Serialize() ([]byte, error) {
+	o, err := mo.Order()
+	if err != nil {
+		return nil, err
+	}
+	return o.Serialize(), nil
+}
+
+// NewCancelOrder creates the payload of a cancel order.
+func NewCancelOrder(co *order.CancelOrder) *CancelOrder {
+	return &CancelOrder{
+		Prefix:   newPrefix(&co.P),
+		TargetID: co.TargetOrderID.Bytes(),
+	}
+}
+
+// Order converts the payload to a cancel order.
+func (co *CancelOrder) Order() (*order.CancelOrder, error) {
+	p, err := co.Prefix.order(order.CancelOrderType)
+	if err != nil {
+		return nil, err
+	}
+	if len(co.TargetID) != order.OrderIDSize {
+		return nil, fmt.Errorf("target order ID is %d bytes, expected %d", len(co.TargetID), order.OrderIDSize)
+	}
+	o := &order.CancelOrder{P: *p}
+	copy(o.TargetOrderID[:], co.TargetID)
+	return o, nil
+}
+
+// Serialize gives the bytes the client signs, the order's serialization. It
+// is an error if the payload is not a valid order.
+func (co *CancelOrder) Serialize() ([]byte, error) {
+	o, err := co.Order()
+	if err != nil {
+		return nil, err
+	}
+	return o.Serialize(), nil
+}
+
+// Match roles on the wire.
+const (
+	Maker = 0
+	Taker = 1
+)
+
+// Match is a payload of a MatchRoute request, telling a client that its order
+// was matched, and where to send the swap.
+type Match struct {
+	Signature
+	OrderID    dex.Bytes `json:"orderid"`
+	MatchID    dex.Bytes `json:"matchid"`
+	Quantity   uint64    `json:"qty"`
+	Rate       uint64    `json:"rate"`
+	ServerTime uint64    `json:"tserver"`
+	Address    string    `json:"address"`
+	Side       uint8     `json:"side"`
+}
+
+// NewMatch creates the match payload for the taker or maker of the match.
+// address is the counterparty's address.
+func NewMatch(m *order.Match, taker bool, address string) *Match {
+	oid, side := m.Maker, uint8(Maker)
+	if taker {
+		oid, side = m.Taker, Taker
+	}
+	mid := m.ID()
+	return &Match{
+		OrderID:    oid.Bytes(),
+		MatchID:    mid.Bytes(),
+		Quantity:   m.Quantity,
+		Rate:       m.Rate,
+		ServerTime: encode.UnixMilliU(m.ServerTime),
+		Address:    address,
+		Side:       side,
+	}
+}
+
+// Serialize gives the bytes the server signs.
+func (m *Match) Serialize() ([]byte, error) {
+	b := make([]byte, 0, len(m.OrderID)+len(m.MatchID)+8+8+8+len(m.Address)+1)
+	b = append(b, m.OrderID...)
+	b = append(b, m.MatchID...)
+	b = append(b, uint64Bytes(m.Quantity)...)
+	b = append(b, uint64Bytes(m.Rate)...)
+	b = append(b, uint64Bytes(m.ServerTime)...)
+	b = append(b, []byte(m.Address)...)
+	return append(b, m.Side), nil
+}
+
+// Acknowledgement is the result of a match, audit or redemption request, with
+// the client's signature of the request payload.
+type Acknowledgement struct {
+	MatchID dex.Bytes `json:"matchid"`
+	Sig     dex.Bytes `json:"sig"`
+}
+
+// Init is the payload of an InitRoute request, reporting the contract the
+// client sent for a match.
+type Init struct {
+	Signature
+	OrderID  dex.Bytes `json:"orderid"`
+	MatchID  dex.Bytes `json:"matchid"`
+	CoinID   dex.Bytes `json:"coinid"`
+	Contract dex.Bytes `json:"contract"`
+}
+
+// Serialize gives the bytes the client signs.
+func (init *Init) Serialize() ([]byte, error) {
+	b := make([]byte, 0, len(init.OrderID)+len(init.MatchID)+len(init.CoinID)+len(init.Contract))
+	b = append(b, init.OrderID...)
+	b = append(b, init.MatchID...)
+	b = append(b, init.CoinID...)
+	return append(b, init.Contract...), nil
+}
+
+// Audit is the payload of an AuditRoute request, giving a client the
+// counterparty's contract to audit.
+type Audit struct {
+	Signature
+	OrderID  dex.Bytes `json:"orderid"`
+	MatchID  dex.Bytes `json:"matchid"`
+	Time     uint64    `json:"timestamp"`
+	CoinID   dex.Bytes `json:"coinid"`
+	Contract dex.Bytes `json:"contract"`
+}
+
+// Serialize gives the bytes the server signs.
+func (audit *Audit) Serialize() ([]byte, error) {
+	b := make([]byte, 0, len(audit.OrderID)+len(audit.MatchID)+8+len(audit.CoinID)+len(audit.Contract))
+	b = append(b, audit.OrderID...)
+	b = append(b, audit.MatchID...)
+	b = append(b, uint64Bytes(audit.Time)...)
+	b = append(b, audit.CoinID...)
+	return append(b, audit.Contract...), nil
+}
+
+// Redeem is the payload of a RedeemRoute request, reporting the redemption the
+// client sent for a match.
+type Redeem struct {
+	Signature
+	OrderID dex.Bytes `json:"orderid"`
+	MatchID dex.Bytes `json:"matchid"`
+	CoinID  dex.Bytes `json:"coinid"`
+	Secret  dex.Bytes `json:"secret"`
+}
+
+// Serialize gives the bytes the client signs.
+func (redeem *Redeem) Serialize() ([]byte, error) {
+	b := make([]byte, 0, len(redeem.OrderID)+len(redeem.MatchID)+len(redeem.CoinID)+len(redeem.Secret))
+	b = append(b, redeem.OrderID...)
+	b = append(b, redeem.MatchID...)
+	b = append(b, redeem.CoinID...)
+	return append(b, redeem.Secret...), nil
+}
+
+// PreimageRequest is the payload of a PreimageRoute request, asking a client
+// for the preimage of its order's commitment.
+type PreimageRequest struct {
+	OrderID    dex.Bytes `json:"orderid"`
+	Commitment dex.Bytes `json:"commit"`
+}
+
+// PreimageResponse is the result of a PreimageRoute request.
+type PreimageResponse struct {
+	Preimage dex.Bytes `json:"pimg"`
+}
+
+// OrderPreimage converts the response to an order.Preimage, checking its
+// length.
+func (pr *PreimageResponse) OrderPreimage() (order.Preimage, error) {
+	var pi order.Preimage
+	if len(pr.Preimage) != order.PreimageSize {
+		return pi, fmt.Errorf("preimage is %d bytes, expected %d", len(pr.Preimage), order.PreimageSize)
+	}
+	copy(pi[:], pr.Preimage)
+	return pi, nil
+}
+
+// EpochReport is the payload of an EpochReportRoute notification, summarizing
+// the matching of a closed epoch.
+type EpochReport struct {
+	MarketID    string    `json:"marketid"`
+	Epoch       uint64    `json:"epoch"`
+	MatchVolume uint64    `json:"matchvolume"`
+	HighRate    uint64    `json:"highrate"`
+	LowRate     uint64    `json:"lowrate"`
+	Orders      uint32    `json:"orders"`
+	Misses      uint32    `json:"misses"`
+	Seed        dex.Bytes `json:"seed"`
+}
+
+// Ensure the signed payloads are Signable.
+var (
+	_ Signable = (*LimitOrder)(nil)
+	_ Signable = (*InstantOrder)(nil)
+	_ Signable = (*CancelOrder)(nil)
+	_ Signable = (*Match)(nil)
+	_ Signable = (*Init)(nil)
+	_ Signable = (*Audit)(nil)
+	_ Signable = (*Redeem)(nil)
+)
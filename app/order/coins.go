@@ -0,0 +1,97 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package order
+
+import (
+	"fmt"
+	"github.com/skynet0590/inswap/app"
+)
+
+// AssetString returns the human-readable form of the coin ID of the asset, or
+// its hex encoding if it is not a valid coin ID of the asset.
+func (c CoinID) AssetString(assetID uint32) string {
+	s, err := app.DecodeCoinID(assetID, c)
+	if err != nil {
+		return c.String()
+	}
+	return s
+}
+
+// FundingAsset returns the asset that funds a trade order, the base asset for
+// sells and the quote asset for buys. Cancel orders have no funding asset.
+func FundingAsset(ord Order) (uint32, bool) {
+	t := ord.Trade()
+	if t == nil {
+		return 0, false
+	}
+	if t.Sell {
+		return ord.Base(), true
+	}
+	return ord.Quote(), true
+}
+
+// ValidateCoins ensures that a trade order's coins are distinct, well-formed
+// coin IDs of its funding asset, all of the same size. Coin IDs are decoded if
+// the asset has a decoder, see app.ValidateCoinID. Cancel orders have no coins
+// and are not checked.
+func ValidateCoins(ord Order) error {
+	assetID, ok := FundingAsset(ord)
+	if !ok {
+		return nil
+	}
+	coins := ord.Trade().Coins
+	seen := make(map[string]bool, len(coins))
+	for i, coinID := range coins {
+		if len(coinID) != len(coins[0]) {
+			return fmt.Errorf("coin %d ID is %d bytes, but coin 0 is %d", i, len(coinID), len(coins[0]))
+		}
+		if err := app.ValidateCoinID(assetID, coinID); err != nil {
+			return fmt.Errorf("coin %d: %w", i, err)
+		}
+		if seen[string(coinID)] {
+			return fmt.Errorf("duplicate coin %s", coinID.AssetString(assetID))
+		}
+		seen[string(coinID)] = true
+	}
+	return nil
+}
+
+// CoinChecker is the part of an asset backend used to check funding coins.
+type CoinChecker interface {
+	// UnspentCoin returns the value and confirmations of the unspent coin, or
+	// an error if the coin is unknown or spent.
+	UnspentCoin(coinID []byte) (value uint64, confs uint32, err error)
+}
+
+// CheckFundingCoins ensures that a trade order's coins are unspent with at
+// least minConfs confirmations, and together worth at least required, which
+// should include the swap fees (see app.Asset.RequiredFunds). The backend must
+// be for the order's funding asset. The coins should first be validated with
+// ValidateCoins.
+func CheckFundingCoins(ord Order, backend CoinChecker, minConfs uint32, required uint64) error {
+	assetID, ok := FundingAsset(ord)
+	if !ok {
+		return nil
+	}
+	var total uint64
+	for _, coinID := range ord.Trade().Coins {
+		value, confs, err := backend.UnspentCoin(coinID)
+		if err != nil {
+			return fmt.Errorf("funding coin %s: %w", coinID.AssetString(assetID), err)
+		}
+		if confs < minConfs {
+			return fmt.Errorf("funding coin %s has %d confirmations, %d required",
+				coinID.AssetString(assetID), confs, minConfs)
+		}
+		if total+value < total {
+			return app.NewError(app.AmountOverflowError, fmt.Sprintf("total value of %d %s funding coins",
+				len(ord.Trade().Coins), app.BipIDSymbol(assetID)))
+		}
+		total += value
+	}
+	if total < required {
+		return fmt.Errorf("funding coins are worth %d, %d required", total, required)
+	}
+	return nil
+}
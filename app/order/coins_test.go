@@ -0,0 +1,100 @@
+package order
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/skynet0590/inswap/app"
+)
+
+type tCoinChecker map[string]struct {
+	value uint64
+	confs uint32
+}
+
+var errCoinSpent = errors.New("coin spent")
+
+func (c tCoinChecker) UnspentCoin(coinID []byte) (uint64, uint32, error) {
+	coin, found := c[string(coinID)]
+	if !found {
+		return 0, 0, errCoinSpent
+	}
+	return coin.value, coin.confs, nil
+}
+
+func testCoinID(b byte) CoinID {
+	coinID := make(CoinID, 36)
+	coinID[0] = b
+	return coinID
+}
+
+func TestValidateCoins(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		coins []CoinID
+		sell  bool
+		quote uint32
+		ok    bool
+	}{
+		{"one", []CoinID{testCoinID(1)}, true, 0, true},
+		{"two", []CoinID{testCoinID(1), testCoinID(2)}, true, 0, true},
+		{"buy", []CoinID{testCoinID(1)}, false, 0, true},
+		{"short", []CoinID{testCoinID(1)[:35]}, true, 0, false},
+		{"mixed", []CoinID{testCoinID(1), append(testCoinID(2), 0)}, true, 0, false},
+		{"duplicate", []CoinID{testCoinID(1), testCoinID(1)}, true, 0, false},
+		// Buys are funded by the quote asset. Without a decoder, its coin IDs
+		// need only be non-empty.
+		{"undecoded quote", []CoinID{testCoinID(1)}, false, 60, true},
+		{"undecoded quote short", []CoinID{{1, 2}, {3, 4}}, false, 60, true},
+		{"undecoded quote empty", []CoinID{{}}, false, 60, false},
+	} {
+		lo := newTestLimitOrder(StandingTiF)
+		lo.Coins, lo.Sell, lo.QuoteAsset = tt.coins, tt.sell, tt.quote
+		if err := ValidateCoins(lo); (err == nil) != tt.ok {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if err := ValidateOrder(lo, OrderStatusEpoch, 1e8); (err == nil) != tt.ok {
+			t.Errorf("%s: unexpected ValidateOrder error %v", tt.name, err)
+		}
+	}
+	if err := ValidateCoins(&CancelOrder{}); err != nil {
+		t.Fatalf("cancel order coins checked: %v", err)
+	}
+	if s := testCoinID(1).AssetString(42); !strings.HasSuffix(s, "01:0") {
+		t.Fatalf("unexpected coin string %q", s)
+	}
+}
+
+func TestCheckFundingCoins(t *testing.T) {
+	backend := tCoinChecker{
+		string(testCoinID(1)): {value: 2e8, confs: 3},
+		string(testCoinID(2)): {value: 1e8, confs: 1},
+	}
+	lo := newTestLimitOrder(StandingTiF)
+	lo.Coins = []CoinID{testCoinID(1), testCoinID(2)}
+	if err := CheckFundingCoins(lo, backend, 1, 3e8); err != nil {
+		t.Fatalf("funded order rejected: %v", err)
+	}
+	if err := CheckFundingCoins(lo, backend, 1, 3e8+1); err == nil {
+		t.Fatalf("underfunded order accepted")
+	}
+	if err := CheckFundingCoins(lo, backend, 2, 0); err == nil {
+		t.Fatalf("unconfirmed coin accepted")
+	}
+	lo.Coins = append(lo.Coins, testCoinID(3))
+	if err := CheckFundingCoins(lo, backend, 1, 0); !errors.Is(err, errCoinSpent) {
+		t.Fatalf("expected spent coin error, got %v", err)
+	}
+
+	// Coins worth more than a uint64 in total are an error, not a wrapped
+	// total that might cover the requirement.
+	backend[string(testCoinID(3))] = struct {
+		value uint64
+		confs uint32
+	}{value: math.MaxUint64, confs: 1}
+	if err := CheckFundingCoins(lo, backend, 1, 1); !errors.Is(err, app.AmountOverflowError) {
+		t.Fatalf("expected overflow error, got %v", err)
+	}
+}
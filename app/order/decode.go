@@ -0,0 +1,284 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package order
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/decred/dcrd/crypto/blake256"
+	"github.com/skynet0590/inswap/app"
+	"github.com/skynet0590/inswap/app/encode"
+)
+
+// The limits of a Trade's serialization, where the coin count and each length
+// is a single byte.
+const (
+	MaxCoins       = math.MaxUint8
+	MaxCoinIDSize  = math.MaxUint8
+	MaxAddressSize = math.MaxUint8
+)
+
+// decodeTime decodes a serialized millisecond timestamp.
+func decodeTime(b []byte) time.Time {
+	return encode.UnixTimeMilli(int64(binary.BigEndian.Uint64(b)))
+}
+
+// DecodePrefix decodes a serialized order Prefix, which must be exactly
+// PrefixLen bytes.
+func DecodePrefix(b []byte) (*Prefix, error) {
+	if len(b) != PrefixLen {
+		return nil, fmt.Errorf("prefix is %d bytes, expected %d", len(b), PrefixLen)
+	}
+	p := new(Prefix)
+	offset := copy(p.AccountID[:], b)
+	p.BaseAsset = binary.BigEndian.Uint32(b[offset : offset+4])
+	offset += 4
+	p.QuoteAsset = binary.BigEndian.Uint32(b[offset : offset+4])
+	offset += 4
+	p.OrderType = OrderType(b[offset])
+	offset++
+	p.ClientTime = decodeTime(b[offset : offset+8])
+	offset += 8
+	p.ServerTime = decodeTime(b[offset : offset+8])
+	offset += 8
+	copy(p.Commit[:], b[offset:])
+	return p, nil
+}
+
+// decodeTrade decodes a serialized Trade from the start of b into t, returning
+// the bytes that follow it.
+func decodeTrade(b []byte, t *Trade) ([]byte, error) {
+	short := func(what string) error {
+		return fmt.Errorf("trade too short for %s", what)
+	}
+	if len(b) < 1 {
+		return nil, short("coin count")
+	}
+	numCoins := int(b[0])
+	b = b[1:]
+	t.Coins = make([]CoinID, 0, numCoins)
+	for i := 0; i < numCoins; i++ {
+		if len(b) < 1 {
+			return nil, short(fmt.Sprintf("coin %d length", i))
+		}
+		coinSz := int(b[0])
+		b = b[1:]
+		if len(b) < coinSz {
+			return nil, short(fmt.Sprintf("coin %d", i))
+		}
+		t.Coins = append(t.Coins, CoinID(encode.CopySlice(b[:coinSz])))
+		b = b[coinSz:]
+	}
+	if len(b) < 1+8+1 {
+		return nil, short("side and quantity")
+	}
+	switch b[0] {
+	case 0:
+	case 1:
+		t.Sell = true
+	default:
+		return nil, fmt.Errorf("invalid order side %d", b[0])
+	}
+	t.Quantity = binary.BigEndian.Uint64(b[1:9])
+	addrLen := int(b[9])
+	b = b[10:]
+	if len(b) < addrLen {
+		return nil, short("address")
+	}
+	t.Address = string(b[:addrLen])
+	return b[addrLen:], nil
+}
+
+// DecodeTrade decodes a serialized Trade, which must have no trailing bytes.
+func DecodeTrade(b []byte) (*Trade, error) {
+	t := new(Trade)
+	rest, err := decodeTrade(b, t)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%d unexpected bytes after trade", len(rest))
+	}
+	return t, nil
+}
+
+// DecodeOrder decodes a serialized order of any type. The order's type is given
+// by its prefix, and the serialization must be exactly the length of that type
+// of order. The decoded order has the same ID as the serialized one.
+func DecodeOrder(b []byte) (Order, error) {
+	if len(b) < PrefixLen {
+		return nil, fmt.Errorf("order is %d bytes, shorter than a prefix", len(b))
+	}
+	p, err := DecodePrefix(b[:PrefixLen])
+	if err != nil {
+		return nil, err
+	}
+	b = b[PrefixLen:]
+
+	switch p.OrderType {
+	case CancelOrderType:
+		if len(b) != OrderIDSize {
+			return nil, fmt.Errorf("cancel order target is %d bytes, expected %d", len(b), OrderIDSize)
+		}
+		co := &CancelOrder{P: *p}
+		copy(co.TargetOrderID[:], b)
+		return co, nil
+
+	case InstantOrderType:
+		mo := &InstantOrder{P: *p}
+		rest, err := decodeTrade(b, &mo.T)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) > 0 {
+			return nil, fmt.Errorf("%d unexpected bytes after instant order", len(rest))
+		}
+		return mo, nil
+
+	case LimitOrderType:
+		lo := &LimitOrder{P: *p}
+		rest, err := decodeTrade(b, &lo.T)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) != 8+1 {
+			return nil, fmt.Errorf("limit order rate and time in force are %d bytes, expected 9", len(rest))
+		}
+		lo.Rate = binary.BigEndian.Uint64(rest[:8])
+		lo.Force = TimeInForce(rest[8])
+		switch lo.Force {
+		case ImmediateTiF, StandingTiF:
+		default:
+			return nil, fmt.Errorf("unknown time in force %d", lo.Force)
+		}
+		return lo, nil
+	}
+
+	return nil, fmt.Errorf("unknown order type %d", p.OrderType)
+}
+
+// legacyCoinIDSizes are the coin ID sizes of the assets that could fund orders
+// serialized in the legacy layout, which has no coin ID lengths.
+var legacyCoinIDSizes = map[uint32]int{
+	0:  app.UTXOCoinIDSize, // btc
+	2:  app.UTXOCoinIDSize, // ltc
+	42: app.UTXOCoinIDSize, // dcr
+}
+
+// decodeLegacyTrade decodes a Trade serialized in the legacy layout, where the
+// coin IDs are coinSz bytes each and the address is not preceded by its
+// length, so it is the trade's last tailLen bytes excepted.
+func decodeLegacyTrade(b []byte, t *Trade, coinSz, tailLen int) ([]byte, error) {
+	if len(b) < 1 {
+		return nil, fmt.Errorf("trade too short for coin count")
+	}
+	numCoins := int(b[0])
+	b = b[1:]
+	if len(b) < numCoins*coinSz+1+8+tailLen {
+		return nil, fmt.Errorf("trade too short for %d coins of %d bytes", numCoins, coinSz)
+	}
+	t.Coins = make([]CoinID, 0, numCoins)
+	for i := 0; i < numCoins; i++ {
+		t.Coins = append(t.Coins, CoinID(encode.CopySlice(b[:coinSz])))
+		b = b[coinSz:]
+	}
+	switch b[0] {
+	case 0:
+	case 1:
+		t.Sell = true
+	default:
+		return nil, fmt.Errorf("invalid order side %d", b[0])
+	}
+	t.Quantity = binary.BigEndian.Uint64(b[1:9])
+	b = b[9:]
+	addrLen := len(b) - tailLen
+	t.Address = string(b[:addrLen])
+	return b[addrLen:], nil
+}
+
+// legacyCoinIDSize finds the coin ID size of the funding asset of a trade
+// serialized in the legacy layout. The side follows the coin IDs, so the funding
+// asset is the one whose coin ID size puts the side it funds there: the base
+// asset for sells and the quote asset for buys.
+func legacyCoinIDSize(p *Prefix, b []byte) (int, error) {
+	if len(b) < 1 {
+		return 0, fmt.Errorf("trade too short for coin count")
+	}
+	numCoins := int(b[0])
+	for _, funding := range []struct {
+		assetID uint32
+		side    byte
+	}{{p.BaseAsset, 1}, {p.QuoteAsset, 0}} {
+		sz, found := legacyCoinIDSizes[funding.assetID]
+		if !found {
+			continue
+		}
+		if sideOffset := 1 + numCoins*sz; len(b) > sideOffset && b[sideOffset] == funding.side {
+			return sz, nil
+		}
+	}
+	return 0, fmt.Errorf("no legacy coin ID size of asset %d or %d fits the trade", p.BaseAsset, p.QuoteAsset)
+}
+
+// decodeLegacyOrder decodes an order serialized in the legacy layout of
+// version 0 blobs, which predates the coin ID and address lengths. The coin
+// IDs are decoded with the fixed coin ID size of the order's funding asset. The
+// order keeps the ID of the legacy serialization, which Serialize no longer
+// produces.
+func decodeLegacyOrder(b []byte) (Order, error) {
+	if len(b) < PrefixLen {
+		return nil, fmt.Errorf("order is %d bytes, shorter than a prefix", len(b))
+	}
+	p, err := DecodePrefix(b[:PrefixLen])
+	if err != nil {
+		return nil, err
+	}
+	p.setLegacyID(blake256.Sum256(b))
+	tb := b[PrefixLen:]
+
+	switch p.OrderType {
+	case CancelOrderType:
+		if len(tb) != OrderIDSize {
+			return nil, fmt.Errorf("cancel order target is %d bytes, expected %d", len(tb), OrderIDSize)
+		}
+		co := &CancelOrder{P: *p}
+		copy(co.TargetOrderID[:], tb)
+		return co, nil
+
+	case InstantOrderType:
+		sz, err := legacyCoinIDSize(p, tb)
+		if err != nil {
+			return nil, err
+		}
+		mo := &InstantOrder{P: *p}
+		if _, err = decodeLegacyTrade(tb, &mo.T, sz, 0); err != nil {
+			return nil, err
+		}
+		return mo, nil
+
+	case LimitOrderType:
+		sz, err := legacyCoinIDSize(p, tb)
+		if err != nil {
+			return nil, err
+		}
+		lo := &LimitOrder{P: *p}
+		rest, err := decodeLegacyTrade(tb, &lo.T, sz, 8+1)
+		if err != nil {
+			return nil, err
+		}
+		lo.Rate = binary.BigEndian.Uint64(rest[:8])
+		lo.Force = TimeInForce(rest[8])
+		switch lo.Force {
+		case ImmediateTiF, StandingTiF:
+		default:
+			return nil, fmt.Errorf("unknown time in force %d", lo.Force)
+		}
+		return lo, nil
+	}
+
+	return nil, fmt.Errorf("unknown order type %d", p.OrderType)
+}
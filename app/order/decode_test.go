@@ -0,0 +1,126 @@
+package order
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testOrders() []Order {
+	lo := newTestLimitOrder(StandingTiF)
+	lo.Coins = append(lo.Coins, CoinID{1, 2, 3})
+	mo := &InstantOrder{
+		P: Prefix{
+			AccountID:  [32]byte{7},
+			BaseAsset:  42,
+			QuoteAsset: 0,
+			OrderType:  InstantOrderType,
+			ClientTime: time.Unix(1600000000, 123e6),
+			ServerTime: time.Unix(1600000001, 456e6),
+			Commit:     Commitment{9},
+		},
+		T: Trade{
+			Coins:    []CoinID{make([]byte, 36)},
+			Quantity: 12345,
+			Address:  "bc1qtestaddress",
+		},
+	}
+	co := &CancelOrder{
+		P: Prefix{
+			BaseAsset:  42,
+			QuoteAsset: 0,
+			OrderType:  CancelOrderType,
+			ClientTime: time.Unix(1600000000, 0),
+			ServerTime: time.Unix(1600000001, 0),
+		},
+		TargetOrderID: lo.ID(),
+	}
+	return []Order{lo, mo, co}
+}
+
+func TestDecodeOrder(t *testing.T) {
+	for _, ord := range testOrders() {
+		b := ord.Serialize()
+		decoded, err := DecodeOrder(b)
+		if err != nil {
+			t.Fatalf("%s order decode error: %v", ord.Type(), err)
+		}
+		if decoded.ID() != ord.ID() {
+			t.Fatalf("%s order ID changed after decode", ord.Type())
+		}
+		if reflect.TypeOf(decoded) != reflect.TypeOf(ord) {
+			t.Fatalf("decoded %T, expected %T", decoded, ord)
+		}
+		if tr := ord.Trade(); tr != nil {
+			dt := decoded.Trade()
+			if !reflect.DeepEqual(dt.Coins, tr.Coins) || dt.Sell != tr.Sell ||
+				dt.Quantity != tr.Quantity || dt.Address != tr.Address {
+				t.Fatalf("%s order trade mismatch", ord.Type())
+			}
+		}
+
+		// Every truncation and any extra byte is an error.
+		for i := 0; i < len(b); i++ {
+			if _, err = DecodeOrder(b[:i]); err == nil {
+				t.Fatalf("%s order truncated to %d bytes decoded", ord.Type(), i)
+			}
+		}
+		if _, err = DecodeOrder(append(b, 0)); err == nil {
+			t.Fatalf("%s order with an extra byte decoded", ord.Type())
+		}
+	}
+
+	lo := newTestLimitOrder(StandingTiF)
+	b := lo.Serialize()
+	b[len(b)-1] = 9
+	if _, err := DecodeOrder(b); err == nil {
+		t.Fatalf("unknown time in force decoded")
+	}
+	b = lo.Serialize()
+	b[PrefixLen-CommitmentSize-8-8-1] = 200
+	if _, err := DecodeOrder(b); err == nil {
+		t.Fatalf("unknown order type decoded")
+	}
+}
+
+func TestDecodePrefixAndTrade(t *testing.T) {
+	lo := newTestLimitOrder(ImmediateTiF)
+	p, err := DecodePrefix(lo.P.Serialize())
+	if err != nil {
+		t.Fatalf("DecodePrefix error: %v", err)
+	}
+	if !reflect.DeepEqual(p.Serialize(), lo.P.Serialize()) || !p.ServerTime.Equal(lo.ServerTime) {
+		t.Fatalf("prefix mismatch")
+	}
+	if _, err = DecodePrefix(append(lo.P.Serialize(), 0)); err == nil {
+		t.Fatalf("long prefix decoded")
+	}
+
+	tr, err := DecodeTrade(lo.T.Serialize())
+	if err != nil {
+		t.Fatalf("DecodeTrade error: %v", err)
+	}
+	if !reflect.DeepEqual(tr.Serialize(), lo.T.Serialize()) {
+		t.Fatalf("trade mismatch")
+	}
+	b := lo.T.Serialize()
+	b[1+1+36] = 2 // side
+	if _, err = DecodeTrade(b); err == nil {
+		t.Fatalf("invalid side decoded")
+	}
+}
+
+func TestValidateTradeLimits(t *testing.T) {
+	for _, modify := range []func(lo *LimitOrder){
+		func(lo *LimitOrder) { lo.Coins = nil },
+		func(lo *LimitOrder) { lo.Coins = []CoinID{make([]byte, MaxCoinIDSize+1)} },
+		func(lo *LimitOrder) { lo.Address = "" },
+		func(lo *LimitOrder) { lo.Address = string(make([]byte, MaxAddressSize+1)) },
+	} {
+		lo := newTestLimitOrder(StandingTiF)
+		modify(lo)
+		if err := ValidateOrder(lo, OrderStatusEpoch, 1e8); err == nil {
+			t.Errorf("unserializable trade validated")
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package order
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/skynet0590/inswap/app/encode"
+)
+
+// Orders and matches are stored as versioned blobs built with
+// encode.BuildyBytes, so that fields can be added without breaking stored data.
+// The serialization that IDs are computed from is always the first push.
+//
+// Version 0 order blobs are the legacy serialization alone, without coin ID and
+// address lengths. Version 1 is the current serialization alone. Version 2 adds
+// the filled amount and the client's signature, and version 3 replaces the
+// filled amount with the order's fills. Version 4 adds the ID of an order
+// decoded from a version 0 blob, which is empty for other orders, since the
+// current serialization does not give the legacy ID. Version 0 match blobs are the match
+// serialization alone, and version 1 adds the taker's and maker's signatures.
+// Any version can be decoded.
+const (
+	// OrderEncodingVersion is the version of blobs created by EncodeOrder.
+	OrderEncodingVersion = 4
+	// MatchEncodingVersion is the version of blobs created by EncodeMatch.
+	MatchEncodingVersion = 1
+)
+
+// EncodeOrder encodes the order, its fills and the client's signature in a
+// versioned blob. An order decoded from a version 0 blob keeps its legacy ID.
+func EncodeOrder(ord Order, sig []byte) ([]byte, error) {
+	b := ord.Serialize()
+	var fills []byte
+	if t := ord.Trade(); t != nil {
+		for _, f := range t.Fills() {
+			fills = append(fills, f.serialize()...)
+		}
+	}
+	if len(b) > math.MaxUint16 || len(fills) > math.MaxUint16 || len(sig) > math.MaxUint16 {
+		return nil, fmt.Errorf("order, fills or signature too large to encode")
+	}
+	var legacyID []byte
+	if id := ord.Prefix().legacyID; id != nil {
+		legacyID = id[:]
+	}
+	return encode.BuildyBytes{OrderEncodingVersion}.AddData(b).
+		AddData(fills).AddData(sig).AddData(legacyID), nil
+}
+
+// DecodeOrderBlob decodes an order blob of any version, returning the order and
+// the client's signature, which is nil for versions 0 and 1.
+func DecodeOrderBlob(b []byte) (Order, []byte, error) {
+	ver, pushes, err := encode.DecodeBlob(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch ver {
+	case 0:
+		if len(pushes) != 1 {
+			return nil, nil, fmt.Errorf("version 0 order blob has %d pushes, expected 1", len(pushes))
+		}
+		ord, err := decodeLegacyOrder(pushes[0])
+		return ord, nil, err
+	case 1:
+		if len(pushes) != 1 {
+			return nil, nil, fmt.Errorf("version 1 order blob has %d pushes, expected 1", len(pushes))
+		}
+		ord, err := DecodeOrder(pushes[0])
+		return ord, nil, err
+	case 2:
+		if len(pushes) != 3 {
+			return nil, nil, fmt.Errorf("version 2 order blob has %d pushes, expected 3", len(pushes))
+		}
+		ord, err := DecodeOrder(pushes[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(pushes[1]) != 8 {
+			return nil, nil, fmt.Errorf("filled amount is %d bytes, expected 8", len(pushes[1]))
+		}
+		// The filled amount becomes a fill without a match.
+		filled := binary.BigEndian.Uint64(pushes[1])
+		if filled == 0 {
+			return ord, pushes[2], nil
+		}
+		if err = addFills(ord, []Fill{{Quantity: filled}}); err != nil {
+			return nil, nil, err
+		}
+		return ord, pushes[2], nil
+	case 3, 4:
+		// Version 4 adds the legacy ID push.
+		expected := 3
+		if ver == 4 {
+			expected = 4
+		}
+		if len(pushes) != expected {
+			return nil, nil, fmt.Errorf("version %d order blob has %d pushes, expected %d", ver, len(pushes), expected)
+		}
+		ord, err := DecodeOrder(pushes[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		fills, err := decodeFills(pushes[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = addFills(ord, fills); err != nil {
+			return nil, nil, err
+		}
+		if ver == 4 && len(pushes[3]) > 0 {
+			if len(pushes[3]) != OrderIDSize {
+				return nil, nil, fmt.Errorf("legacy order ID is %d bytes, expected %d", len(pushes[3]), OrderIDSize)
+			}
+			var id OrderID
+			copy(id[:], pushes[3])
+			ord.Prefix().setLegacyID(id)
+		}
+		return ord, pushes[2], nil
+	}
+	return nil, nil, fmt.Errorf("unknown order blob version %d", ver)
+}
+
+// addFills records the decoded fills of the order.
+func addFills(ord Order, fills []Fill) error {
+	if len(fills) == 0 {
+		return nil
+	}
+	t := ord.Trade()
+	if t == nil {
+		return fmt.Errorf("%s order has fills", ord.Type())
+	}
+	for _, f := range fills {
+		if err := t.AddFill(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeMatch encodes the match and the taker's and maker's signatures in a
+// versioned blob.
+func EncodeMatch(m *Match, takerSig, makerSig []byte) ([]byte, error) {
+	if len(takerSig) > math.MaxUint16 || len(makerSig) > math.MaxUint16 {
+		return nil, fmt.Errorf("signature too large to encode")
+	}
+	return encode.BuildyBytes{MatchEncodingVersion}.AddData(m.Serialize()).
+		AddData(takerSig).AddData(makerSig), nil
+}
+
+// DecodeMatchBlob decodes a match blob of any version, returning the match and
+// the taker's and maker's signatures, which are nil for version 0.
+func DecodeMatchBlob(b []byte) (m *Match, takerSig, makerSig []byte, err error) {
+	ver, pushes, err := encode.DecodeBlob(b)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	switch ver {
+	case 0:
+		if len(pushes) != 1 {
+			return nil, nil, nil, fmt.Errorf("version 0 match blob has %d pushes, expected 1", len(pushes))
+		}
+		m, err = DecodeMatch(pushes[0])
+		return m, nil, nil, err
+	case 1:
+		if len(pushes) != 3 {
+			return nil, nil, nil, fmt.Errorf("version 1 match blob has %d pushes, expected 3", len(pushes))
+		}
+		m, err = DecodeMatch(pushes[0])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return m, pushes[1], pushes[2], nil
+	}
+	return nil, nil, nil, fmt.Errorf("unknown match blob version %d", ver)
+}
@@ -0,0 +1,205 @@
+package order
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/crypto/blake256"
+	"github.com/skynet0590/inswap/app/encode"
+)
+
+func TestOrderBlob(t *testing.T) {
+	sig := []byte{1, 2, 3}
+	for _, ord := range testOrders() {
+		if tr := ord.Trade(); tr != nil {
+			fills := []Fill{
+				{MatchID: MatchID{1}, Quantity: tr.Quantity / 2, Rate: 1e6, Time: time.Unix(1600000002, 0).UTC()},
+				{MatchID: MatchID{2}, Quantity: tr.Quantity, Rate: 1e6, Time: time.Unix(1600000003, 0).UTC(),
+					Status: FillRevoked},
+			}
+			for _, f := range fills {
+				if err := tr.AddFill(f); err != nil {
+					t.Fatalf("AddFill error: %v", err)
+				}
+			}
+		}
+		b, err := EncodeOrder(ord, sig)
+		if err != nil {
+			t.Fatalf("EncodeOrder error: %v", err)
+		}
+		if b[0] != OrderEncodingVersion {
+			t.Fatalf("wrong version %d", b[0])
+		}
+		decoded, decodedSig, err := DecodeOrderBlob(b)
+		if err != nil {
+			t.Fatalf("%s order DecodeOrderBlob error: %v", ord.Type(), err)
+		}
+		if decoded.ID() != ord.ID() || !bytes.Equal(decodedSig, sig) {
+			t.Fatalf("%s order mismatch", ord.Type())
+		}
+		if tr := ord.Trade(); tr != nil {
+			dt := decoded.Trade()
+			if dt.Filled() != tr.Filled() || !reflect.DeepEqual(dt.Fills(), tr.Fills()) {
+				t.Fatalf("fills %+v, expected %+v", dt.Fills(), tr.Fills())
+			}
+		}
+
+		// Version 1 blobs hold only the serialization.
+		v1 := encode.BuildyBytes{1}.AddData(ord.Serialize())
+		decoded, decodedSig, err = DecodeOrderBlob(v1)
+		if err != nil {
+			t.Fatalf("%s order version 1 decode error: %v", ord.Type(), err)
+		}
+		if decoded.ID() != ord.ID() || decodedSig != nil {
+			t.Fatalf("%s order version 1 mismatch", ord.Type())
+		}
+	}
+
+	ord := testOrders()[0]
+	for _, bad := range [][]byte{
+		nil,
+		encode.BuildyBytes{3}.AddData(ord.Serialize()),
+		encode.BuildyBytes{0}.AddData(ord.Serialize()).AddData(nil),
+		encode.BuildyBytes{1}.AddData(ord.Serialize()).AddData(nil),
+		encode.BuildyBytes{2}.AddData(ord.Serialize()).AddData([]byte{1}).AddData(nil),
+		encode.BuildyBytes{2}.AddData(ord.Serialize()).AddData(encode.Uint64Bytes(3e8)).AddData(nil),
+		encode.BuildyBytes{3}.AddData(ord.Serialize()).AddData([]byte{1}).AddData(nil),
+		encode.BuildyBytes{4}.AddData(ord.Serialize()).AddData(nil).AddData(nil),
+		encode.BuildyBytes{4}.AddData(ord.Serialize()).AddData(nil).AddData(nil).AddData([]byte{1}),
+		encode.BuildyBytes{5}.AddData(ord.Serialize()).AddData(nil).AddData(nil).AddData(nil),
+	} {
+		if _, _, err := DecodeOrderBlob(bad); err == nil {
+			t.Errorf("bad blob %x decoded", bad)
+		}
+	}
+}
+
+// legacySerialize serializes the order in the legacy layout of version 0 order
+// blobs, without coin ID and address lengths.
+func legacySerialize(ord Order) []byte {
+	b := ord.Prefix().Serialize()
+	switch o := ord.(type) {
+	case *CancelOrder:
+		return append(b, o.TargetOrderID[:]...)
+	case *InstantOrder:
+		return append(b, legacySerializeTrade(&o.T)...)
+	case *LimitOrder:
+		b = append(b, legacySerializeTrade(&o.T)...)
+		return append(append(b, encode.Uint64Bytes(o.Rate)...), byte(o.Force))
+	}
+	return nil
+}
+
+func legacySerializeTrade(t *Trade) []byte {
+	b := []byte{byte(len(t.Coins))}
+	for _, coinID := range t.Coins {
+		b = append(b, coinID...)
+	}
+	var side byte
+	if t.Sell {
+		side = 1
+	}
+	b = append(append(b, side), encode.Uint64Bytes(t.Quantity)...)
+	return append(b, t.Address...)
+}
+
+func TestOrderBlobV0(t *testing.T) {
+	// Version 0 blobs have the legacy serialization, which is decoded with the
+	// funding asset's coin ID size and keeps its ID.
+	buy := newTestLimitOrder(ImmediateTiF)
+	buy.Sell = false
+	buy.Coins = append(buy.Coins, make([]byte, 36))
+	orders := testOrders()
+	for _, ord := range []Order{newTestLimitOrder(StandingTiF), buy, orders[1], orders[2]} {
+		legacy := legacySerialize(ord)
+		decoded, sig, err := DecodeOrderBlob(encode.BuildyBytes{0}.AddData(legacy))
+		if err != nil {
+			t.Fatalf("%s order version 0 decode error: %v", ord.Type(), err)
+		}
+		if sig != nil {
+			t.Fatalf("%s order version 0 has a signature", ord.Type())
+		}
+		if decoded.ID() != OrderID(blake256.Sum256(legacy)) {
+			t.Fatalf("%s order version 0 ID changed", ord.Type())
+		}
+		if !bytes.Equal(decoded.Serialize(), ord.Serialize()) {
+			t.Fatalf("%s order version 0 mismatch", ord.Type())
+		}
+
+		// The legacy ID survives re-encoding in the current version.
+		b, err := EncodeOrder(decoded, []byte{1})
+		if err != nil {
+			t.Fatalf("%s order EncodeOrder error: %v", ord.Type(), err)
+		}
+		reencoded, sig, err := DecodeOrderBlob(b)
+		if err != nil {
+			t.Fatalf("%s order re-encoded decode error: %v", ord.Type(), err)
+		}
+		if reencoded.ID() != decoded.ID() || !bytes.Equal(sig, []byte{1}) {
+			t.Fatalf("%s order ID changed by re-encoding", ord.Type())
+		}
+		// Restamping the order gives it a new ID.
+		reencoded.Prefix().SetTime(time.Unix(1600000009, 0))
+		if b, _ = EncodeOrder(reencoded, nil); !bytes.Equal(b, encode.BuildyBytes{OrderEncodingVersion}.
+			AddData(reencoded.Serialize()).AddData(nil).AddData(nil).AddData(nil)) {
+			t.Fatalf("%s order kept its legacy ID after restamping", ord.Type())
+		}
+	}
+
+	// The coin IDs of an asset without a legacy coin ID size cannot be found.
+	ord := newTestLimitOrder(StandingTiF)
+	ord.BaseAsset = 60
+	ord.QuoteAsset = 61
+	if _, _, err := DecodeOrderBlob(encode.BuildyBytes{0}.AddData(legacySerialize(ord))); err == nil {
+		t.Fatalf("no error for an unknown legacy coin ID size")
+	}
+}
+
+func TestOrderBlobV2(t *testing.T) {
+	// Version 2 blobs have a filled amount, which becomes a fill without a
+	// match.
+	ord := newTestLimitOrder(StandingTiF)
+	b := encode.BuildyBytes{2}.AddData(ord.Serialize()).AddData(encode.Uint64Bytes(1e8)).AddData([]byte{1})
+	decoded, sig, err := DecodeOrderBlob(b)
+	if err != nil {
+		t.Fatalf("DecodeOrderBlob error: %v", err)
+	}
+	fills := decoded.Trade().Fills()
+	if len(fills) != 1 || fills[0].Quantity != 1e8 || fills[0].MatchID != (MatchID{}) || !bytes.Equal(sig, []byte{1}) {
+		t.Fatalf("unexpected fills %+v", fills)
+	}
+	if decoded.Trade().Remaining() != 1e8 {
+		t.Fatalf("wrong remaining amount %d", decoded.Trade().Remaining())
+	}
+}
+
+func TestMatchBlob(t *testing.T) {
+	orders := testOrders()
+	m := &Match{
+		Taker:      orders[1].ID(),
+		Maker:      orders[0].ID(),
+		Quantity:   1e8,
+		Rate:       1e6,
+		ServerTime: time.Unix(1600000002, 0),
+	}
+	b, err := EncodeMatch(m, []byte{1}, []byte{2})
+	if err != nil {
+		t.Fatalf("EncodeMatch error: %v", err)
+	}
+	decoded, takerSig, makerSig, err := DecodeMatchBlob(b)
+	if err != nil {
+		t.Fatalf("DecodeMatchBlob error: %v", err)
+	}
+	if decoded.ID() != m.ID() || !bytes.Equal(takerSig, []byte{1}) || !bytes.Equal(makerSig, []byte{2}) {
+		t.Fatalf("match mismatch")
+	}
+	decoded, takerSig, _, err = DecodeMatchBlob(encode.BuildyBytes{0}.AddData(m.Serialize()))
+	if err != nil || decoded.ID() != m.ID() || takerSig != nil {
+		t.Fatalf("version 0 match mismatch, err = %v", err)
+	}
+	if _, _, _, err = DecodeMatchBlob(encode.BuildyBytes{1}.AddData(m.Serialize()[1:]).AddData(nil).AddData(nil)); err == nil {
+		t.Fatalf("short match decoded")
+	}
+}
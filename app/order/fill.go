@@ -0,0 +1,186 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package order
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// FillStatus is the swap outcome of a fill.
+type FillStatus uint8
+
+// The different FillStatus values.
+const (
+	// FillPending is a fill whose swap is in progress.
+	FillPending FillStatus = iota
+	// FillComplete is a fill whose swap completed.
+	FillComplete
+	// FillRevoked is a fill whose match was revoked because its swap failed.
+	// The quantity of a revoked fill is not filled.
+	FillRevoked
+)
+
+// String returns a string representation of the FillStatus.
+func (s FillStatus) String() string {
+	switch s {
+	case FillPending:
+		return "pending"
+	case FillComplete:
+		return "complete"
+	case FillRevoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
+
+// Fill records a match that filled part of an order. A fill with a zero
+// MatchID is the filled amount of an order stored before fills were recorded.
+type Fill struct {
+	MatchID      MatchID
+	Counterparty OrderID
+	Quantity     uint64
+	Rate         uint64
+	Time         time.Time
+	Status       FillStatus
+}
+
+// NewFill creates the pending fill of the taker or maker order of the match.
+func NewFill(m *Match, taker bool) Fill {
+	counterparty := m.Taker
+	if taker {
+		counterparty = m.Maker
+	}
+	return Fill{
+		MatchID:      m.ID(),
+		Counterparty: counterparty,
+		Quantity:     m.Quantity,
+		Rate:         m.Rate,
+		Time:         m.ServerTime,
+		Status:       FillPending,
+	}
+}
+
+// fillLen is the length in bytes of a serialized Fill.
+const fillLen = MatchIDSize + OrderIDSize + 8 + 8 + 8 + 1
+
+// serialize marshals the Fill into a []byte.
+func (f *Fill) serialize() []byte {
+	b := make([]byte, fillLen)
+	offset := copy(b, f.MatchID[:])
+	offset += copy(b[offset:], f.Counterparty[:])
+	binary.BigEndian.PutUint64(b[offset:offset+8], f.Quantity)
+	offset += 8
+	binary.BigEndian.PutUint64(b[offset:offset+8], f.Rate)
+	offset += 8
+	binary.BigEndian.PutUint64(b[offset:offset+8], unixMilliU(f.Time))
+	offset += 8
+	b[offset] = uint8(f.Status)
+	return b
+}
+
+// decodeFills decodes serialized fills.
+func decodeFills(b []byte) ([]Fill, error) {
+	if len(b)%fillLen != 0 {
+		return nil, fmt.Errorf("fills are %d bytes, not a multiple of %d", len(b), fillLen)
+	}
+	fills := make([]Fill, 0, len(b)/fillLen)
+	for ; len(b) > 0; b = b[fillLen:] {
+		var f Fill
+		offset := copy(f.MatchID[:], b)
+		offset += copy(f.Counterparty[:], b[offset:])
+		f.Quantity = binary.BigEndian.Uint64(b[offset : offset+8])
+		offset += 8
+		f.Rate = binary.BigEndian.Uint64(b[offset : offset+8])
+		offset += 8
+		f.Time = decodeTime(b[offset : offset+8])
+		offset += 8
+		f.Status = FillStatus(b[offset])
+		if f.Status > FillRevoked {
+			return nil, fmt.Errorf("unknown fill status %d", f.Status)
+		}
+		fills = append(fills, f)
+	}
+	return fills, nil
+}
+
+// filled is the quantity of the fills that are not revoked. The fillMtx must be
+// held.
+func (t *Trade) filled() uint64 {
+	var filled uint64
+	for i := range t.fills {
+		if t.fills[i].Status != FillRevoked {
+			filled += t.fills[i].Quantity
+		}
+	}
+	return filled
+}
+
+// Remaining returns the remaining order amount.
+func (t *Trade) Remaining() uint64 {
+	t.fillMtx.RLock()
+	defer t.fillMtx.RUnlock()
+	return t.Quantity - t.filled()
+}
+
+// Filled returns the filled amount, which excludes revoked fills.
+func (t *Trade) Filled() uint64 {
+	t.fillMtx.RLock()
+	defer t.fillMtx.RUnlock()
+	return t.filled()
+}
+
+// Fills returns a copy of the order's fills.
+func (t *Trade) Fills() []Fill {
+	t.fillMtx.RLock()
+	defer t.fillMtx.RUnlock()
+	if t.fills == nil {
+		return nil
+	}
+	return append([]Fill(nil), t.fills...)
+}
+
+// AddFill records a fill. Unless it is revoked, its quantity must not exceed
+// the remaining amount. A match may only fill the order once.
+func (t *Trade) AddFill(f Fill) error {
+	t.fillMtx.Lock()
+	defer t.fillMtx.Unlock()
+	if f.Quantity == 0 {
+		return fmt.Errorf("zero fill quantity")
+	}
+	if remaining := t.Quantity - t.filled(); f.Status != FillRevoked && f.Quantity > remaining {
+		return fmt.Errorf("fill quantity %d exceeds the remaining %d", f.Quantity, remaining)
+	}
+	if f.MatchID != (MatchID{}) {
+		for i := range t.fills {
+			if t.fills[i].MatchID == f.MatchID {
+				return fmt.Errorf("match %s already filled the order", f.MatchID)
+			}
+		}
+	}
+	t.fills = append(t.fills, f)
+	return nil
+}
+
+// SetFillStatus records the swap outcome of the match's fill. Only pending
+// fills may be completed or revoked. Revoking a fill returns its quantity to
+// the remaining amount.
+func (t *Trade) SetFillStatus(mid MatchID, status FillStatus) error {
+	t.fillMtx.Lock()
+	defer t.fillMtx.Unlock()
+	for i := range t.fills {
+		f := &t.fills[i]
+		if f.MatchID != mid {
+			continue
+		}
+		if f.Status != FillPending || status == FillPending || status > FillRevoked {
+			return fmt.Errorf("cannot change %s fill of match %s to %s", f.Status, mid, status)
+		}
+		f.Status = status
+		return nil
+	}
+	return fmt.Errorf("no fill for match %s", mid)
+}
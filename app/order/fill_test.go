@@ -0,0 +1,75 @@
+package order
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFills(t *testing.T) {
+	lo := newTestLimitOrder(StandingTiF) // 2 lots
+	m1 := &Match{Taker: OrderID{1}, Maker: lo.ID(), Quantity: 1e8, Rate: 1e6, ServerTime: time.Unix(1600000002, 0)}
+	m2 := &Match{Taker: OrderID{2}, Maker: lo.ID(), Quantity: 1e8, Rate: 2e6, ServerTime: time.Unix(1600000003, 0)}
+	f1 := NewFill(m1, false)
+	if f1.Counterparty != m1.Taker || f1.MatchID != m1.ID() || f1.Status != FillPending {
+		t.Fatalf("wrong fill %+v", f1)
+	}
+
+	if err := lo.AddFill(f1); err != nil {
+		t.Fatalf("AddFill error: %v", err)
+	}
+	if err := lo.AddFill(f1); err == nil {
+		t.Fatalf("match filled the order twice")
+	}
+	if err := lo.AddFill(NewFill(m2, false)); err != nil {
+		t.Fatalf("AddFill error: %v", err)
+	}
+	if lo.Filled() != 2e8 || lo.Remaining() != 0 {
+		t.Fatalf("filled %d, remaining %d", lo.Filled(), lo.Remaining())
+	}
+	m3 := &Match{Taker: OrderID{3}, Maker: lo.ID(), Quantity: 1e8}
+	if err := lo.AddFill(NewFill(m3, false)); err == nil {
+		t.Fatalf("overfilled order")
+	}
+
+	// Revoking the second fill returns its quantity, and the order can be
+	// filled again.
+	if err := lo.SetFillStatus(f1.MatchID, FillComplete); err != nil {
+		t.Fatalf("SetFillStatus error: %v", err)
+	}
+	if err := lo.SetFillStatus(m2.ID(), FillRevoked); err != nil {
+		t.Fatalf("SetFillStatus error: %v", err)
+	}
+	if lo.Filled() != 1e8 || lo.Remaining() != 1e8 {
+		t.Fatalf("after revoke: filled %d, remaining %d", lo.Filled(), lo.Remaining())
+	}
+	if err := lo.AddFill(NewFill(m3, false)); err != nil {
+		t.Fatalf("AddFill after revoke error: %v", err)
+	}
+	if n := len(lo.Fills()); n != 3 {
+		t.Fatalf("%d fills recorded", n)
+	}
+
+	for _, bad := range []struct {
+		mid    MatchID
+		status FillStatus
+	}{
+		{f1.MatchID, FillRevoked},  // complete is final
+		{m2.ID(), FillComplete},    // revoked is final
+		{m3.ID(), FillPending},     // already pending
+		{m3.ID(), FillStatus(9)},   // unknown status
+		{MatchID{9}, FillComplete}, // no such fill
+	} {
+		if err := lo.SetFillStatus(bad.mid, bad.status); err == nil {
+			t.Errorf("fill of match %s set to %s", bad.mid, bad.status)
+		}
+	}
+
+	// Copies have their own fills.
+	c := lo.T.Copy()
+	if err := c.SetFillStatus(m3.ID(), FillRevoked); err != nil {
+		t.Fatalf("SetFillStatus error: %v", err)
+	}
+	if lo.Remaining() != 0 || c.Remaining() != 1e8 {
+		t.Fatalf("copy shares fills")
+	}
+}
@@ -0,0 +1,109 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package order
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/decred/dcrd/crypto/blake256"
+)
+
+// MatchIDSize defines the length in bytes of a MatchID.
+const MatchIDSize = hashSize
+
+// MatchID is the unique identifier for each match. It is defined as the
+// Blake256 hash of the serialized match.
+type MatchID hash
+
+// String returns a hexadecimal representation of the MatchID. String implements
+// fmt.Stringer.
+func (mid MatchID) String() string {
+	return hex.EncodeToString(mid[:])
+}
+
+// MarshalJSON satisfies the json.Marshaller interface, and will marshal the
+// id to a hex string.
+func (mid MatchID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mid.String())
+}
+
+// Bytes returns the match ID as a []byte.
+func (mid MatchID) Bytes() []byte {
+	return mid[:]
+}
+
+// Value implements the sql/driver.Valuer interface.
+func (mid MatchID) Value() (driver.Value, error) {
+	return mid[:], nil // []byte
+}
+
+// Scan implements the sql.Scanner interface.
+func (mid *MatchID) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		copy(mid[:], src)
+		return nil
+	}
+
+	return fmt.Errorf("cannot convert %T to MatchID", src)
+}
+
+// Match is a match of a taker order with a standing maker order for a quantity
+// of the base asset at the maker's rate.
+type Match struct {
+	Taker      OrderID
+	Maker      OrderID
+	Quantity   uint64
+	Rate       uint64
+	ServerTime time.Time
+
+	id *MatchID // cache of the match's MatchID
+}
+
+// MatchLen is the length in bytes of the serialized Match.
+const MatchLen = OrderIDSize + OrderIDSize + 8 + 8 + 8
+
+// ID computes the match ID.
+func (m *Match) ID() MatchID {
+	if m.id != nil {
+		return *m.id
+	}
+	id := MatchID(blake256.Sum256(m.Serialize()))
+	m.id = &id
+	return id
+}
+
+// Serialize marshals the Match into a []byte.
+func (m *Match) Serialize() []byte {
+	b := make([]byte, MatchLen)
+	offset := copy(b, m.Taker[:])
+	offset += copy(b[offset:], m.Maker[:])
+	binary.BigEndian.PutUint64(b[offset:offset+8], m.Quantity)
+	offset += 8
+	binary.BigEndian.PutUint64(b[offset:offset+8], m.Rate)
+	offset += 8
+	binary.BigEndian.PutUint64(b[offset:offset+8], unixMilliU(m.ServerTime))
+	return b
+}
+
+// DecodeMatch decodes a serialized Match, which must be exactly MatchLen bytes.
+func DecodeMatch(b []byte) (*Match, error) {
+	if len(b) != MatchLen {
+		return nil, fmt.Errorf("match is %d bytes, expected %d", len(b), MatchLen)
+	}
+	m := new(Match)
+	offset := copy(m.Taker[:], b)
+	offset += copy(m.Maker[:], b[offset:])
+	m.Quantity = binary.BigEndian.Uint64(b[offset : offset+8])
+	offset += 8
+	m.Rate = binary.BigEndian.Uint64(b[offset : offset+8])
+	offset += 8
+	m.ServerTime = decodeTime(b[offset : offset+8])
+	return m, nil
+}
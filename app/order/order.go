@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/decred/dcrd/crypto/blake256"
+	"github.com/skynet0590/inswap/app"
 	"github.com/skynet0590/inswap/app/encode"
 	"github.com/skynet0590/inswap/server/account"
 	"sync"
@@ -22,6 +23,7 @@ const (
 	UnknownOrderType OrderType = iota
 	InstantOrderType
 	CancelOrderType
+	LimitOrderType
 )
 
 // Value implements the sql/driver.Valuer interface.
@@ -48,6 +50,34 @@ func (ot OrderType) String() string {
 		return "instant"
 	case CancelOrderType:
 		return "cancel"
+	case LimitOrderType:
+		return "limit"
+	default:
+		return "unknown"
+	}
+}
+
+// TimeInForce indicates how a limit order that is not completely filled in the
+// epoch it is received is handled.
+type TimeInForce uint8
+
+// The different TimeInForce values.
+const (
+	// ImmediateTiF is for orders whose unfilled remainder is executed rather
+	// than booked at the end of the epoch.
+	ImmediateTiF TimeInForce = iota
+	// StandingTiF is for orders whose unfilled remainder is booked, resting
+	// until it is matched or canceled.
+	StandingTiF
+)
+
+// String returns a string representation of the TimeInForce.
+func (t TimeInForce) String() string {
+	switch t {
+	case ImmediateTiF:
+		return "immediate"
+	case StandingTiF:
+		return "standing"
 	default:
 		return "unknown"
 	}
@@ -210,6 +240,10 @@ type Prefix struct {
 	Commit     Commitment
 
 	id *OrderID // cache of the order's OrderID
+	// legacyID is the ID of the legacy serialization of an order decoded from
+	// a version 0 blob, which Serialize no longer produces. EncodeOrder keeps
+	// it so that the order's ID survives re-encoding.
+	legacyID *OrderID
 }
 
 // P is an alias for Prefix. Embedding with the alias allows us to define a
@@ -240,6 +274,13 @@ func (p *Prefix) SetTime(t time.Time) {
 	// SetTime should only ever be called once in practice, but in case it is
 	// necessary to restamp the ServerTime, clear any computed OrderID.
 	p.id = nil
+	p.legacyID = nil
+}
+
+// setLegacyID sets the ID of an order serialized in the legacy layout.
+func (p *Prefix) setLegacyID(id OrderID) {
+	p.id = &id
+	p.legacyID = &id
 }
 
 // User gives the user's account ID.
@@ -302,16 +343,18 @@ func (p *Prefix) Commitment() Commitment {
 }
 
 // Trade is information about a trade-type order. Both limit and market orders
-// are trade-type orders.
+// are trade-type orders. A trade may have at most MaxCoins coins, and the coin
+// IDs and address may be at most MaxCoinIDSize and MaxAddressSize bytes, the
+// limits of their serialization.
 type Trade struct {
 	Coins    []CoinID
 	Sell     bool
 	Quantity uint64
 	Address  string
 
-	// FillAmt is not part of the order's serialization.
-	fillAmtMtx sync.RWMutex
-	FillAmt    uint64 // use Filled and AddFill methods for thread-safe access
+	// The fills are not part of the order's serialization.
+	fillMtx sync.RWMutex
+	fills   []Fill // use the Fills and AddFill methods for thread-safe access
 }
 
 // Copy makes a shallow copy of a Trade. This is useful when attempting to
@@ -323,7 +366,7 @@ func (t *Trade) Copy() *Trade {
 		Sell:     t.Sell,
 		Quantity: t.Quantity,
 		Address:  t.Address,
-		FillAmt:  t.FillAmt,
+		fills:    t.Fills(),
 	}
 }
 
@@ -336,34 +379,6 @@ func (t *Trade) Trade() *Trade {
 	return t
 }
 
-// Remaining returns the remaining order amount.
-func (t *Trade) Remaining() uint64 {
-	t.fillAmtMtx.RLock()
-	defer t.fillAmtMtx.RUnlock()
-	return t.Quantity - t.FillAmt
-}
-
-// Filled returns the filled amount.
-func (t *Trade) Filled() uint64 {
-	t.fillAmtMtx.RLock()
-	defer t.fillAmtMtx.RUnlock()
-	return t.FillAmt
-}
-
-// AddFill increases the filled amount.
-func (t *Trade) AddFill(amt uint64) {
-	t.fillAmtMtx.Lock()
-	t.FillAmt += amt
-	t.fillAmtMtx.Unlock()
-}
-
-// SetFill sets the filled amount.
-func (t *Trade) SetFill(amt uint64) {
-	t.fillAmtMtx.Lock()
-	t.FillAmt = amt
-	t.fillAmtMtx.Unlock()
-}
-
 // SwapAddress returns the order's payment address.
 func (t *Trade) SwapAddress() string {
 	return t.Address
@@ -375,11 +390,11 @@ func (t *Trade) serializeSize() int {
 	var coinSz int
 	for _, coinID := range t.Coins {
 		coinSz += len(coinID)
-		// TODO: ensure all Coin IDs have the same size, indicating the same asset?
 	}
 	// The serialized order includes a byte for coin count, but this is implicit
-	// in coin slice length.
-	return 1 + coinSz + 1 + 8 + len(t.Address)
+	// in coin slice length. Each coin ID and the address are preceded by their
+	// length so that the trade can be decoded.
+	return 1 + len(t.Coins) + coinSz + 1 + 8 + 1 + len(t.Address)
 }
 
 // Serialize marshals the Trade into a []byte.
@@ -391,9 +406,11 @@ func (t *Trade) Serialize() []byte {
 	b[offset] = uint8(len(t.Coins))
 	offset++
 
-	// Coins
+	// Coins, each preceded by its length
 	for _, coinID := range t.Coins {
 		coinSz := len(coinID)
+		b[offset] = uint8(coinSz)
+		offset++
 		copy(b[offset:offset+coinSz], coinID)
 		offset += coinSz
 	}
@@ -410,7 +427,9 @@ func (t *Trade) Serialize() []byte {
 	binary.BigEndian.PutUint64(b[offset:offset+8], t.Quantity)
 	offset += 8
 
-	// client address for received funds
+	// client address for received funds, preceded by its length
+	b[offset] = uint8(len(t.Address))
+	offset++
 	copy(b[offset:offset+len(t.Address)], []byte(t.Address))
 	return b
 }
@@ -452,7 +471,6 @@ func (o *InstantOrder) serializeSize() int {
 	var coinSz int
 	for _, coinID := range o.Coins {
 		coinSz += len(coinID)
-		// TODO: ensure all Coin IDs have the same size, indicating the same asset?
 	}
 	// The serialized order includes a byte for coin count, but this is implicit
 	// in coin slice length.
@@ -473,6 +491,65 @@ func (o *InstantOrder) Serialize() []byte {
 // Ensure MarketOrder is an Order.
 var _ Order = (*InstantOrder)(nil)
 
+// LimitOrder defines a limit order in terms of a Prefix, the trade details, a
+// rate and a time in force. The order quantity is in atoms of the base asset,
+// and must be an integral multiple of the market's lot size. The rate is in
+// quote asset atoms per base asset atom, times the rate encoding factor, and
+// must be an integral multiple of the quote asset's rate step.
+type LimitOrder struct {
+	P
+	T
+	Rate  uint64
+	Force TimeInForce
+}
+
+// ID computes the order ID.
+func (o *LimitOrder) ID() OrderID {
+	if o.id != nil {
+		return *o.id
+	}
+	id := calcOrderID(o)
+	o.id = &id
+	return id
+}
+
+// UID computes the order ID, returning the string representation.
+func (o *LimitOrder) UID() string {
+	return o.ID().String()
+}
+
+// String is the same as UID. It is defined to satisfy Stringer.
+func (o *LimitOrder) String() string {
+	return o.UID()
+}
+
+// serializeSize returns the length of the serialized LimitOrder.
+func (o *LimitOrder) serializeSize() int {
+	return o.P.serializeSize() + o.T.serializeSize() + 8 + 1
+}
+
+// Serialize marshals the LimitOrder into a []byte.
+func (o *LimitOrder) Serialize() []byte {
+	b := make([]byte, o.serializeSize())
+	// Prefix and data common with InstantOrder
+	offset := o.P.serializeSize()
+	copy(b[:offset], o.P.Serialize())
+	tradeLen := o.T.serializeSize()
+	copy(b[offset:offset+tradeLen], o.T.Serialize())
+	offset += tradeLen
+
+	// rate
+	binary.BigEndian.PutUint64(b[offset:offset+8], o.Rate)
+	offset += 8
+
+	// time in force
+	b[offset] = uint8(o.Force)
+	return b
+}
+
+// Ensure LimitOrder is an Order.
+var _ Order = (*LimitOrder)(nil)
+
 // CancelOrder defines a cancel order in terms of an order Prefix and the ID of
 // the order to be canceled.
 type CancelOrder struct {
@@ -518,6 +595,22 @@ func (o *CancelOrder) Serialize() []byte {
 // Ensure CancelOrder is an Order.
 var _ Order = (*CancelOrder)(nil)
 
+// checkTrade ensures that the trade can be serialized and decoded.
+func checkTrade(t *Trade) error {
+	if len(t.Coins) == 0 || len(t.Coins) > MaxCoins {
+		return fmt.Errorf("trade has %d coins, must have 1 to %d", len(t.Coins), MaxCoins)
+	}
+	for i, coinID := range t.Coins {
+		if len(coinID) == 0 || len(coinID) > MaxCoinIDSize {
+			return fmt.Errorf("coin %d ID is %d bytes, must be 1 to %d", i, len(coinID), MaxCoinIDSize)
+		}
+	}
+	if len(t.Address) == 0 || len(t.Address) > MaxAddressSize {
+		return fmt.Errorf("address is %d bytes, must be 1 to %d", len(t.Address), MaxAddressSize)
+	}
+	return nil
+}
+
 // ValidateOrder ensures that the order with the given status for the specified
 // market is sensible. The ServerTime may not be set yet, so the OrderID cannot
 // be computed.
@@ -526,6 +619,15 @@ func ValidateOrder(ord Order, status OrderStatus, lotSize uint64) error {
 		return fmt.Errorf("same asset specified for base and quote")
 	}
 
+	if t := ord.Trade(); t != nil {
+		if err := checkTrade(t); err != nil {
+			return err
+		}
+		if err := ValidateCoins(ord); err != nil {
+			return err
+		}
+	}
+
 	// Each order type has different rules about status and lot size.
 	switch ot := ord.(type) {
 	case *InstantOrder:
@@ -543,17 +645,50 @@ func ValidateOrder(ord Order, status OrderStatus, lotSize uint64) error {
 
 		// Market sell orders must respect lot size. Market buy orders must be
 		// of an amount sufficiently buffered beyond the minimum standing sell
-		// order's lot cost, but that is enforced by the order router.
+		// order's lot cost, which depends on the book and is checked by the
+		// order router with ValidateMarketBuy.
 		if ot.Sell && (ot.Quantity%lotSize != 0 || ot.Remaining()%lotSize != 0) {
 			return fmt.Errorf("market sell order fails lot size requirement %d %% %d = %d", ot.Quantity, lotSize, ot.Quantity%lotSize)
 		}
 
+	case *LimitOrder:
+		// Limit order OK statuses: epoch, booked, executed, canceled and
+		// revoked. Only standing orders may be booked or canceled.
+		switch status {
+		case OrderStatusEpoch, OrderStatusExecuted, OrderStatusRevoked:
+		case OrderStatusBooked, OrderStatusCanceled:
+			if ot.Force != StandingTiF {
+				return fmt.Errorf("invalid %s limit order status %d -> %s", ot.Force, status, status)
+			}
+		default:
+			return fmt.Errorf("invalid limit order status %d -> %s", status, status)
+		}
+
+		if ot.OrderType != LimitOrderType {
+			return fmt.Errorf("limit order has wrong order type %d -> %s", ot.OrderType, ot.OrderType)
+		}
+
+		switch ot.Force {
+		case ImmediateTiF, StandingTiF:
+		default:
+			return fmt.Errorf("limit order has unknown time in force %d", ot.Force)
+		}
+
+		if ot.Rate == 0 {
+			return fmt.Errorf("limit order has zero rate")
+		}
+
+		// Limit orders must respect lot size on both sides.
+		if ot.Quantity%lotSize != 0 || ot.Remaining()%lotSize != 0 {
+			return fmt.Errorf("limit order fails lot size requirement %d %% %d = %d", ot.Quantity, lotSize, ot.Quantity%lotSize)
+		}
+
 	case *CancelOrder:
-		// Cancel order OK statuses: epoch, executed (NOT booked or canceled),
-		// and revoked. Revoked status indicates the cancel order is
+		// Cancel order OK statuses: epoch, executed, failed (NOT booked or
+		// canceled), and revoked. Revoked status indicates the cancel order is
 		// server-generated and corresponds to a revoked trade order.
 		switch status {
-		case OrderStatusEpoch, OrderStatusExecuted, OrderStatusRevoked: // orderStatusFailed if we decide to export that
+		case OrderStatusEpoch, OrderStatusExecuted, OrderStatusRevoked, OrderStatusFailed:
 		default:
 			return fmt.Errorf("invalid cancel order status %d -> %s", status, status)
 		}
@@ -570,6 +705,33 @@ func ValidateOrder(ord Order, status OrderStatus, lotSize uint64) error {
 	return nil
 }
 
+// ValidateMarketBuy ensures that a market buy order's quantity, in quote asset
+// atoms, covers the market's buy buffer times the cost of one lot at the best
+// sell rate on the book. Other orders are not checked.
+func ValidateMarketBuy(ord Order, mkt *app.MarketInfo, bestSellRate uint64) error {
+	ot, ok := ord.(*InstantOrder)
+	if !ok || ot.Sell {
+		return nil
+	}
+	return mkt.CheckMarketBuy(ot.Quantity, bestSellRate)
+}
+
+// ValidateRateStep ensures that a limit order's rate is an integral multiple of
+// the quote asset's rate step. Other orders have no rate and are not checked.
+func ValidateRateStep(ord Order, rateStep uint64) error {
+	lo, ok := ord.(*LimitOrder)
+	if !ok {
+		return nil
+	}
+	if rateStep == 0 {
+		return fmt.Errorf("zero rate step")
+	}
+	if lo.Rate%rateStep != 0 {
+		return fmt.Errorf("limit order fails rate step requirement %d %% %d = %d", lo.Rate, rateStep, lo.Rate%rateStep)
+	}
+	return nil
+}
+
 // Some commonly used time transformations.
 var unixMilli = encode.UnixMilli
 var unixMilliU = encode.UnixMilliU
@@ -0,0 +1,90 @@
+package order
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimitOrder(force TimeInForce) *LimitOrder {
+	return &LimitOrder{
+		P: Prefix{
+			BaseAsset:  42,
+			QuoteAsset: 0,
+			OrderType:  LimitOrderType,
+			ClientTime: time.Unix(1600000000, 0),
+			ServerTime: time.Unix(1600000001, 0),
+		},
+		T: Trade{
+			Coins:    []CoinID{make([]byte, 36)},
+			Sell:     true,
+			Quantity: 2e8,
+			Address:  "DsTestAddress",
+		},
+		Rate:  1e6,
+		Force: force,
+	}
+}
+
+func TestLimitOrderSerialize(t *testing.T) {
+	lo := newTestLimitOrder(StandingTiF)
+	b := lo.Serialize()
+	if len(b) != PrefixLen+1+1+36+1+8+1+len(lo.Address)+8+1 {
+		t.Fatalf("wrong serialized length %d", len(b))
+	}
+	if b[len(b)-1] != uint8(StandingTiF) {
+		t.Fatalf("time in force not serialized last")
+	}
+	id := lo.ID()
+	lo.Force = ImmediateTiF
+	lo.SetTime(lo.ServerTime)
+	if lo.ID() == id {
+		t.Fatalf("time in force not part of the order ID")
+	}
+}
+
+func TestValidateLimitOrder(t *testing.T) {
+	const lotSize = 1e8
+	for _, tt := range []struct {
+		name   string
+		force  TimeInForce
+		status OrderStatus
+		modify func(lo *LimitOrder)
+		ok     bool
+	}{
+		{"standing booked", StandingTiF, OrderStatusBooked, nil, true},
+		{"standing canceled", StandingTiF, OrderStatusCanceled, nil, true},
+		{"immediate epoch", ImmediateTiF, OrderStatusEpoch, nil, true},
+		{"immediate executed", ImmediateTiF, OrderStatusExecuted, nil, true},
+		{"immediate booked", ImmediateTiF, OrderStatusBooked, nil, false},
+		{"immediate canceled", ImmediateTiF, OrderStatusCanceled, nil, false},
+		{"unknown status", StandingTiF, OrderStatusUnknown, nil, false},
+		{"wrong type", StandingTiF, OrderStatusEpoch, func(lo *LimitOrder) { lo.OrderType = InstantOrderType }, false},
+		{"unknown force", StandingTiF, OrderStatusEpoch, func(lo *LimitOrder) { lo.Force = 2 }, false},
+		{"zero rate", StandingTiF, OrderStatusEpoch, func(lo *LimitOrder) { lo.Rate = 0 }, false},
+		{"lot size", StandingTiF, OrderStatusEpoch, func(lo *LimitOrder) { lo.Quantity++ }, false},
+		{"buy lot size", StandingTiF, OrderStatusEpoch, func(lo *LimitOrder) { lo.Sell = false; lo.Quantity++ }, false},
+		{"same assets", StandingTiF, OrderStatusEpoch, func(lo *LimitOrder) { lo.QuoteAsset = 42 }, false},
+	} {
+		lo := newTestLimitOrder(tt.force)
+		if tt.modify != nil {
+			tt.modify(lo)
+		}
+		err := ValidateOrder(lo, tt.status, lotSize)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+	}
+}
+
+func TestValidateRateStep(t *testing.T) {
+	lo := newTestLimitOrder(StandingTiF)
+	if err := ValidateRateStep(lo, 1e5); err != nil {
+		t.Fatalf("valid rate rejected: %v", err)
+	}
+	if err := ValidateRateStep(lo, 3e5); err == nil {
+		t.Fatalf("rate step not enforced")
+	}
+	if err := ValidateRateStep(&CancelOrder{}, 3e5); err != nil {
+		t.Fatalf("cancel order rate checked: %v", err)
+	}
+}
@@ -3,6 +3,12 @@
 
 package order
 
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
 // OrderStatus indicates the state of an order.
 type OrderStatus uint16
 
@@ -38,12 +44,7 @@ const (
 	// necessarily be completely unfilled. Partially filled orders that are
 	// still on the order book remain in OrderStatusBooked.
 	//
-	// Note: The DB driver must be able to distinguish cancel orders that have
-	// not matched from those that were not matched, but OrderStatusExecuted
-	// will be returned for both such orders, although a new exported status may
-	// be added so the consumer can query this information (TODO). The DB knows
-	// the match status for cancel orders and how the cancel order was finalized
-	// (ExecuteOrder for matched, and FailCancelOrder for unmatched).
+	// Cancel orders that were not matched have OrderStatusFailed instead.
 	OrderStatusExecuted
 
 	// OrderStatusCanceled is for orders that were on the book, but matched with
@@ -55,6 +56,10 @@ const (
 	// standing limit orders that were matched but have failed to swap (neither
 	// executed nor canceled), and preimage misses.
 	OrderStatusRevoked
+
+	// OrderStatusFailed is for cancel orders that were processed but not
+	// matched, because their target order was not on the book.
+	OrderStatusFailed
 )
 
 var orderStatusNames = map[OrderStatus]string{
@@ -64,13 +69,104 @@ var orderStatusNames = map[OrderStatus]string{
 	OrderStatusExecuted: "executed",
 	OrderStatusCanceled: "canceled",
 	OrderStatusRevoked:  "revoked",
+	OrderStatusFailed:   "failed",
 }
 
-// String implements Stringer.
+// String implements Stringer. Unknown values are printed as numbers.
 func (s OrderStatus) String() string {
 	name, ok := orderStatusNames[s]
 	if !ok {
-		panic("unknown order status!") // programmer error
+		return fmt.Sprintf("OrderStatus(%d)", uint16(s))
 	}
 	return name
 }
+
+// IsValid checks that the status is one of the defined values.
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderStatusNames[s]
+	return ok
+}
+
+// ParseOrderStatus returns the OrderStatus with the name.
+func ParseOrderStatus(name string) (OrderStatus, error) {
+	for s, n := range orderStatusNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return OrderStatusUnknown, fmt.Errorf("unknown order status %q", name)
+}
+
+// MarshalText implements encoding.TextMarshaler, which is also used for JSON.
+func (s OrderStatus) MarshalText() ([]byte, error) {
+	if !s.IsValid() {
+		return nil, fmt.Errorf("unknown order status %d", uint16(s))
+	}
+	return []byte(orderStatusNames[s]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, which is also used for
+// JSON.
+func (s *OrderStatus) UnmarshalText(b []byte) error {
+	status, err := ParseOrderStatus(string(b))
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// Value implements the sql/driver.Valuer interface.
+func (s OrderStatus) Value() (driver.Value, error) {
+	if !s.IsValid() {
+		return nil, fmt.Errorf("unknown order status %d", uint16(s))
+	}
+	return int64(s), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *OrderStatus) Scan(src interface{}) error {
+	v := new(sql.NullInt32)
+	if err := v.Scan(src); err != nil {
+		return err
+	}
+	status := OrderStatus(v.Int32)
+	if v.Int32 < 0 || !status.IsValid() {
+		return fmt.Errorf("unknown order status %d", v.Int32)
+	}
+	*s = status
+	return nil
+}
+
+// statusTransitions are the allowed status changes of each type of order. A
+// new order is OrderStatusUnknown until it is validated and enters an epoch.
+var statusTransitions = map[OrderType]map[OrderStatus][]OrderStatus{
+	LimitOrderType: {
+		OrderStatusUnknown: {OrderStatusEpoch},
+		// Unmatched standing orders are booked, and the others executed.
+		// Preimage misses are revoked.
+		OrderStatusEpoch:  {OrderStatusBooked, OrderStatusExecuted, OrderStatusRevoked},
+		OrderStatusBooked: {OrderStatusExecuted, OrderStatusCanceled, OrderStatusRevoked},
+	},
+	InstantOrderType: {
+		OrderStatusUnknown: {OrderStatusEpoch},
+		OrderStatusEpoch:   {OrderStatusExecuted, OrderStatusRevoked},
+	},
+	CancelOrderType: {
+		// Cancel orders created by the server for revoked orders start out
+		// revoked.
+		OrderStatusUnknown: {OrderStatusEpoch, OrderStatusRevoked},
+		OrderStatusEpoch:   {OrderStatusExecuted, OrderStatusFailed, OrderStatusRevoked},
+	},
+}
+
+// CanTransition checks that an order of the type may change from one status to
+// another. Executed, canceled, revoked and failed orders are final.
+func CanTransition(from, to OrderStatus, ot OrderType) bool {
+	for _, s := range statusTransitions[ot][from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package order
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	for _, tt := range []struct {
+		from, to OrderStatus
+		ot       OrderType
+		ok       bool
+	}{
+		{OrderStatusUnknown, OrderStatusEpoch, LimitOrderType, true},
+		{OrderStatusEpoch, OrderStatusBooked, LimitOrderType, true},
+		{OrderStatusBooked, OrderStatusCanceled, LimitOrderType, true},
+		{OrderStatusBooked, OrderStatusRevoked, LimitOrderType, true},
+		{OrderStatusEpoch, OrderStatusCanceled, LimitOrderType, false},
+		{OrderStatusExecuted, OrderStatusBooked, LimitOrderType, false},
+		{OrderStatusBooked, OrderStatusBooked, LimitOrderType, false},
+		{OrderStatusEpoch, OrderStatusBooked, InstantOrderType, false},
+		{OrderStatusEpoch, OrderStatusExecuted, InstantOrderType, true},
+		{OrderStatusEpoch, OrderStatusFailed, CancelOrderType, true},
+		{OrderStatusEpoch, OrderStatusFailed, LimitOrderType, false},
+		{OrderStatusUnknown, OrderStatusRevoked, CancelOrderType, true},
+		{OrderStatusFailed, OrderStatusExecuted, CancelOrderType, false},
+		{OrderStatusUnknown, OrderStatusEpoch, UnknownOrderType, false},
+	} {
+		if CanTransition(tt.from, tt.to, tt.ot) != tt.ok {
+			t.Errorf("%s order %s -> %s: expected %v", tt.ot, tt.from, tt.to, tt.ok)
+		}
+	}
+}
+
+func TestOrderStatusEncoding(t *testing.T) {
+	if s := OrderStatus(99).String(); s != "OrderStatus(99)" {
+		t.Fatalf("unknown status string %q", s)
+	}
+	for s := range orderStatusNames {
+		b, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("%s marshal error: %v", s, err)
+		}
+		var s2 OrderStatus
+		if err = json.Unmarshal(b, &s2); err != nil || s2 != s {
+			t.Fatalf("%s JSON round trip got %s, err = %v", s, s2, err)
+		}
+		v, err := s.Value()
+		if err != nil {
+			t.Fatalf("%s Value error: %v", s, err)
+		}
+		if err = s2.Scan(v); err != nil || s2 != s {
+			t.Fatalf("%s SQL round trip got %s, err = %v", s, s2, err)
+		}
+	}
+	if b, _ := json.Marshal(OrderStatusFailed); string(b) != `"failed"` {
+		t.Fatalf("unexpected JSON %s", b)
+	}
+
+	var s OrderStatus
+	if _, err := json.Marshal(OrderStatus(99)); err == nil {
+		t.Fatalf("unknown status marshalled")
+	}
+	if err := json.Unmarshal([]byte(`"pending"`), &s); err == nil {
+		t.Fatalf("unknown status name unmarshalled")
+	}
+	if _, err := OrderStatus(99).Value(); err == nil {
+		t.Fatalf("unknown status valued")
+	}
+	if err := s.Scan(int64(99)); err == nil {
+		t.Fatalf("unknown status scanned")
+	}
+	if err := s.Scan(int64(-1)); err == nil {
+		t.Fatalf("negative status scanned")
+	}
+}
@@ -0,0 +1,182 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	dexbtc "decred.org/dcrdex/dex/networks/btc"
+	dexdcr "decred.org/dcrdex/dex/networks/dcr"
+)
+
+const (
+	UnknownAssetError = ErrorKind("unknown asset")
+	InvalidAssetError = ErrorKind("invalid asset")
+)
+
+// assetRegistry holds the parameters of each asset on each network, so that
+// clients and the server agree on them.
+var assetRegistry = struct {
+	sync.RWMutex
+	assets map[Network]map[uint32]*Asset
+}{
+	assets: make(map[Network]map[uint32]*Asset),
+}
+
+func init() {
+	// The swap sizes are those of the dcrdex wallets' swap transactions, which
+	// use P2WSH contracts for Bitcoin and P2SH contracts for Litecoin. The
+	// redeem and refund sizes are of transactions spending a single contract.
+	btc := Asset{ID: 0, Symbol: "btc", Decimals: 8, LotSize: 100000, RateStep: 100000,
+		MaxFeeRate: 100, SwapSize: dexbtc.InitTxSizeSegwit, SwapSizeBase: dexbtc.InitTxSizeBaseSegwit,
+		RedeemSize: btcSpendContractSize(dexbtc.RedeemSwapSigScriptSize, true),
+		RefundSize: btcSpendContractSize(dexbtc.RefundSigScriptSize, true),
+		SwapConf:   1, BlockTime: 600000}
+	ltc := Asset{ID: 2, Symbol: "ltc", Decimals: 8, LotSize: 1000000, RateStep: 1000000,
+		MaxFeeRate: 20, SwapSize: dexbtc.InitTxSize, SwapSizeBase: dexbtc.InitTxSizeBase,
+		RedeemSize: btcSpendContractSize(dexbtc.RedeemSwapSigScriptSize, false),
+		RefundSize: btcSpendContractSize(dexbtc.RefundSigScriptSize, false),
+		SwapConf:   6, BlockTime: 150000}
+	dcr := Asset{ID: 42, Symbol: "dcr", Decimals: 8, LotSize: 100000000, RateStep: 100000000,
+		MaxFeeRate: 10, SwapSize: dexdcr.InitTxSize, SwapSizeBase: dexdcr.InitTxSizeBase,
+		RedeemSize: dcrSpendContractSize(dexdcr.RedeemSwapSigScriptSize),
+		RefundSize: dcrSpendContractSize(dexdcr.RefundSigScriptSize),
+		SwapConf:   4, BlockTime: 300000}
+	for _, net := range []Network{Mainnet, Testnet, Regtest} {
+		for _, a := range []Asset{btc, ltc, dcr} {
+			// Test networks need only one confirmation.
+			if net != Mainnet {
+				a.SwapConf = 1
+			}
+			if err := RegisterAsset(net, &a); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// btcSpendContractSize estimates the virtual size of a transaction of a Bitcoin
+// clone spending a single contract with the signature script or witness to a
+// single output.
+func btcSpendContractSize(sigScriptSize uint64, segwit bool) uint64 {
+	if segwit {
+		// The witness is discounted, and the marker and flag bytes are
+		// counted with it.
+		return dexbtc.MinimumTxOverhead + dexbtc.TxInOverhead + 1 + dexbtc.P2WPKHOutputSize +
+			(1+sigScriptSize+2+3)/4
+	}
+	return dexbtc.MinimumTxOverhead + dexbtc.TxInOverhead + 3 + sigScriptSize + dexbtc.P2PKHOutputSize
+}
+
+// dcrSpendContractSize estimates the size of a Decred transaction spending a
+// single contract with the signature script to a single output.
+func dcrSpendContractSize(sigScriptSize uint64) uint64 {
+	return dexdcr.MsgTxOverhead + dexdcr.TxInOverhead + 3 + sigScriptSize + dexdcr.P2PKHOutputSize
+}
+
+// Validate checks that the asset's symbol matches its BIP ID and that its
+// parameters are usable.
+func (a *Asset) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return NewError(InvalidAssetError, fmt.Sprintf("%s: ", a.Symbol)+fmt.Sprintf(format, args...))
+	}
+	if sym := BipIDSymbol(a.ID); sym == "" || sym != a.Symbol {
+		return invalid("symbol does not match BIP ID %d (%q)", a.ID, sym)
+	}
+	switch {
+	case a.Decimals > MaxDecimals:
+		return invalid("%d decimal places exceeds the maximum of %d", a.Decimals, MaxDecimals)
+	case a.LotSize == 0:
+		return invalid("zero lot size")
+	case a.RateStep == 0:
+		return invalid("zero rate step")
+	case a.MaxFeeRate == 0:
+		return invalid("zero max fee rate")
+	case a.SwapSizeBase == 0 || a.SwapSize <= a.SwapSizeBase:
+		return invalid("swap size %d must exceed the swap size base %d, which must be non-zero",
+			a.SwapSize, a.SwapSizeBase)
+	case a.RedeemSize == 0 || a.RefundSize == 0:
+		return invalid("zero redeem or refund size")
+	case a.SwapConf == 0:
+		return invalid("zero swap confirmations")
+	case a.BlockTime == 0:
+		return invalid("zero block time")
+	}
+	return nil
+}
+
+func validNetwork(net Network) bool {
+	return net == Mainnet || net == Testnet || net == Regtest
+}
+
+// RegisterAsset validates and registers a copy of the asset's parameters on the
+// network. An asset may only be registered once per network.
+func RegisterAsset(net Network, a *Asset) error {
+	if !validNetwork(net) {
+		return fmt.Errorf("unknown network %d", net)
+	}
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	assetRegistry.Lock()
+	defer assetRegistry.Unlock()
+	assets := assetRegistry.assets[net]
+	if assets == nil {
+		assets = make(map[uint32]*Asset)
+		assetRegistry.assets[net] = assets
+	}
+	if _, dup := assets[a.ID]; dup {
+		return NewError(InvalidAssetError, fmt.Sprintf("%s already registered on %s", a.Symbol, net))
+	}
+	ac := *a
+	assets[a.ID] = &ac
+	return nil
+}
+
+// AssetByID returns a copy of the parameters of the asset with the BIP ID on
+// the network.
+func AssetByID(net Network, assetID uint32) (*Asset, error) {
+	assetRegistry.RLock()
+	defer assetRegistry.RUnlock()
+	a, found := assetRegistry.assets[net][assetID]
+	if !found {
+		return nil, NewError(UnknownAssetError, fmt.Sprintf("asset %d not registered on %s", assetID, net))
+	}
+	ac := *a
+	return &ac, nil
+}
+
+// AssetBySymbol returns a copy of the parameters of the asset with the symbol
+// on the network.
+func AssetBySymbol(net Network, symbol string) (*Asset, error) {
+	assetID, found := BipSymbolID(strings.ToLower(symbol))
+	if !found {
+		return nil, NewError(UnknownAssetError, fmt.Sprintf("unknown symbol %q", symbol))
+	}
+	return AssetByID(net, assetID)
+}
+
+// Assets returns copies of the parameters of the assets registered on the
+// network, sorted by BIP ID.
+func Assets(net Network) []*Asset {
+	assetRegistry.RLock()
+	assets := make([]*Asset, 0, len(assetRegistry.assets[net]))
+	for _, a := range assetRegistry.assets[net] {
+		ac := *a
+		assets = append(assets, &ac)
+	}
+	assetRegistry.RUnlock()
+	sort.Slice(assets, func(i, j int) bool { return assets[i].ID < assets[j].ID })
+	return assets
+}
+
+// AssetsJSON exports the parameters of the assets registered on the network as
+// a JSON array.
+func AssetsJSON(net Network) ([]byte, error) {
+	return json.Marshal(Assets(net))
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestAssetRegistry(t *testing.T) {
+	for _, net := range []Network{Mainnet, Testnet, Regtest} {
+		for _, symbol := range []string{"btc", "ltc", "dcr"} {
+			a, err := AssetBySymbol(net, symbol)
+			if err != nil {
+				t.Fatalf("%s %s: %v", net, symbol, err)
+			}
+			byID, err := AssetByID(net, a.ID)
+			if err != nil {
+				t.Fatalf("%s %s by ID: %v", net, symbol, err)
+			}
+			if *byID != *a {
+				t.Fatalf("%s %s lookups differ", net, symbol)
+			}
+		}
+	}
+
+	// Lookups return copies.
+	a, _ := AssetBySymbol(Mainnet, "btc")
+	a.LotSize = 1
+	if a2, _ := AssetBySymbol(Mainnet, "BTC"); a2.LotSize == 1 {
+		t.Fatalf("registered asset modified through lookup")
+	}
+
+	if _, err := AssetBySymbol(Mainnet, "doge"); !errors.Is(err, UnknownAssetError) {
+		t.Fatalf("expected unknown asset error, got %v", err)
+	}
+	if _, err := AssetBySymbol(Mainnet, "notacoin"); !errors.Is(err, UnknownAssetError) {
+		t.Fatalf("expected unknown asset error, got %v", err)
+	}
+
+	doge := Asset{ID: 3, Symbol: "doge", Decimals: 8, LotSize: 1e8, RateStep: 1e5,
+		MaxFeeRate: 1000, SwapSize: 225, SwapSizeBase: 76,
+		RedeemSize: 328, RefundSize: 295, SwapConf: 1, BlockTime: 60000}
+	bad := []func(a *Asset){
+		func(a *Asset) { a.Symbol = "btc" },
+		func(a *Asset) { a.Decimals = MaxDecimals + 1 },
+		func(a *Asset) { a.LotSize = 0 },
+		func(a *Asset) { a.RateStep = 0 },
+		func(a *Asset) { a.MaxFeeRate = 0 },
+		func(a *Asset) { a.SwapSize = a.SwapSizeBase },
+		func(a *Asset) { a.RedeemSize = 0 },
+		func(a *Asset) { a.SwapConf = 0 },
+		func(a *Asset) { a.BlockTime = 0 },
+	}
+	for i, mod := range bad {
+		a := doge
+		mod(&a)
+		if err := RegisterAsset(Regtest, &a); !errors.Is(err, InvalidAssetError) {
+			t.Fatalf("bad asset %d: expected invalid asset error, got %v", i, err)
+		}
+	}
+	if err := RegisterAsset(Network(5), &doge); err == nil {
+		t.Fatalf("no error for unknown network")
+	}
+	if err := RegisterAsset(Regtest, &doge); err != nil {
+		t.Fatalf("error registering doge: %v", err)
+	}
+	if err := RegisterAsset(Regtest, &doge); !errors.Is(err, InvalidAssetError) {
+		t.Fatalf("expected error for duplicate registration, got %v", err)
+	}
+	if _, err := AssetByID(Mainnet, doge.ID); !errors.Is(err, UnknownAssetError) {
+		t.Fatalf("doge registered on regtest found on mainnet")
+	}
+
+	b, err := AssetsJSON(Regtest)
+	if err != nil {
+		t.Fatalf("AssetsJSON error: %v", err)
+	}
+	var exported []*Asset
+	if err = json.Unmarshal(b, &exported); err != nil {
+		t.Fatalf("error decoding exported assets: %v", err)
+	}
+	if len(exported) != 4 || exported[0].Symbol != "btc" || *exported[2] != doge {
+		t.Fatalf("unexpected exported assets: %s", b)
+	}
+}
@@ -0,0 +1,80 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package app
+
+import "fmt"
+
+const (
+	// tokenFlag is set in the asset IDs of tokens. SLIP-44 coin types do not
+	// use it.
+	tokenFlag = 1 << 31
+	// maxTokenParentID is the largest parent chain asset ID a token ID can
+	// encode, in its low 16 bits. The token's index on its chain takes the
+	// next 15 bits.
+	maxTokenParentID = 1<<16 - 1
+	maxTokenIndex    = 1<<15 - 1
+)
+
+// Token asset IDs. See TokenID.
+var (
+	USDCEthID = mustTokenID(60, 1)
+	USDTEthID = mustTokenID(60, 2)
+	DAIEthID  = mustTokenID(60, 3)
+)
+
+// tokens are the token symbols, without their parent chain suffix, keyed by
+// token asset ID.
+var tokens = map[uint32]string{
+	USDCEthID: "usdc",
+	USDTEthID: "usdt",
+	DAIEthID:  "dai",
+}
+
+// TokenID creates the asset ID of a token on the parent chain, encoding the
+// parent chain's asset ID and the token's index on the chain, which must be
+// non-zero. Only parent chains with asset IDs below 65536 can have tokens.
+func TokenID(parentID uint32, index uint16) (uint32, error) {
+	if IsToken(parentID) {
+		return 0, fmt.Errorf("parent asset %d is itself a token", parentID)
+	}
+	if parentID > maxTokenParentID {
+		return 0, fmt.Errorf("parent asset %d is too large for a token ID", parentID)
+	}
+	if index == 0 || index > maxTokenIndex {
+		return 0, fmt.Errorf("token index %d is not between 1 and %d", index, maxTokenIndex)
+	}
+	return tokenFlag | uint32(index)<<16 | parentID, nil
+}
+
+func mustTokenID(parentID uint32, index uint16) uint32 {
+	id, err := TokenID(parentID, index)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// IsToken is true if the asset ID is a token ID created by TokenID.
+func IsToken(assetID uint32) bool {
+	return assetID&tokenFlag != 0
+}
+
+// TokenParentID returns the asset ID of the token's parent chain, or the asset
+// ID itself if it is not a token.
+func TokenParentID(assetID uint32) uint32 {
+	if !IsToken(assetID) {
+		return assetID
+	}
+	return assetID & maxTokenParentID
+}
+
+// TokenParent returns the symbol and asset ID of the chain a token is issued
+// on. It returns false if the asset is not a known token.
+func TokenParent(assetID uint32) (parentID uint32, parentSymbol string, found bool) {
+	if _, known := tokens[assetID]; !known {
+		return 0, "", false
+	}
+	parentID = TokenParentID(assetID)
+	return parentID, BipIDSymbol(parentID), true
+}
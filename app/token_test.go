@@ -0,0 +1,51 @@
+package app
+
+import "testing"
+
+func TestTokenIDs(t *testing.T) {
+	id, err := TokenID(60, 1)
+	if err != nil {
+		t.Fatalf("TokenID error: %v", err)
+	}
+	if id != USDCEthID || !IsToken(id) || IsToken(60) {
+		t.Fatalf("unexpected token ID %d", id)
+	}
+	if TokenParentID(id) != 60 || TokenParentID(42) != 42 {
+		t.Fatalf("wrong parent ID")
+	}
+	parentID, parentSymbol, found := TokenParent(id)
+	if !found || parentID != 60 || parentSymbol != "eth" {
+		t.Fatalf("unexpected parent %d %q %v", parentID, parentSymbol, found)
+	}
+	if _, _, found = TokenParent(0); found {
+		t.Fatalf("btc has a parent")
+	}
+
+	for _, bad := range []struct {
+		parentID uint32
+		index    uint16
+	}{{60, 0}, {60, maxTokenIndex + 1}, {maxTokenParentID + 1, 1}, {USDCEthID, 1}} {
+		if _, err = TokenID(bad.parentID, bad.index); err == nil {
+			t.Errorf("no error for parent %d, index %d", bad.parentID, bad.index)
+		}
+	}
+
+	if sym := BipIDSymbol(USDCEthID); sym != "usdc.eth" {
+		t.Fatalf("wrong symbol %q", sym)
+	}
+	if id, found := BipSymbolID("usdc.eth"); !found || id != USDCEthID {
+		t.Fatalf("usdc.eth not found")
+	}
+	if sym := BipIDSymbol(mustTokenID(60, 999)); sym != "" {
+		t.Fatalf("unknown token has symbol %q", sym)
+	}
+
+	name, err := MarketName(USDCEthID, 0)
+	if err != nil || name != "usdc.eth_btc" {
+		t.Fatalf("unexpected market name %q, %v", name, err)
+	}
+	base, quote, err := ParseMarketName("DCR_USDC.ETH")
+	if err != nil || base != 42 || quote != USDCEthID {
+		t.Fatalf("unexpected market %d, %d, %v", base, quote, err)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"decred.org/dcrdex/dex"
+	dexbtc "decred.org/dcrdex/dex/networks/btc"
+	dexdcr "decred.org/dcrdex/dex/networks/dcr"
+	dexltc "decred.org/dcrdex/dex/networks/ltc"
+	"encoding/binary"
+	"fmt"
+	btcchaincfg "github.com/btcsuite/btcd/chaincfg"
+	btctxscript "github.com/btcsuite/btcd/txscript"
+	btcwire "github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	dcrchaincfg "github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	dcrtxscript "github.com/decred/dcrd/txscript/v3"
+	dcrwire "github.com/decred/dcrd/wire"
+	"github.com/skynet0590/inswap/app"
+	"sort"
+	"time"
+)
+
+// contractDetails is the information encoded in a swap contract script.
+type contractDetails struct {
+	sender     string
+	recipient  string
+	lockTime   uint64
+	secretHash []byte
+}
+
+// expiration is the contract's locktime.
+func (d *contractDetails) expiration() time.Time {
+	return time.Unix(int64(d.lockTime), 0)
+}
+
+// swapAsset is an asset that simpleswap can swap. It pairs the asset's
+// parameters in the app asset registry with the chain-specific parsing that
+// the asset.Wallet interface does not expose, such as locating a contract in a
+// raw transaction. The asset's wallet is provided by the dcrdex client driver
+// registered for its BIP ID.
+type swapAsset struct {
+	ID     uint32
+	Symbol string
+	// contractCoinID decodes the raw contract transaction and returns the coin
+	// ID of the output paying to the contract.
+	contractCoinID func(contract, tx []byte) (dex.Bytes, error)
+	// contractDetails decodes the swap contract script.
+	contractDetails func(contract []byte, net dex.Network) (*contractDetails, error)
+	// extractSecret decodes the raw redemption transaction and returns the
+	// secret that hashes to secretHash.
+	extractSecret func(tx, secretHash []byte) ([]byte, error)
+}
+
+// params returns the asset's parameters on the swap network. Every swap asset
+// is registered on every network, so the lookup cannot fail.
+func (a *swapAsset) params() *app.Asset {
+	params, err := app.AssetByID(network, a.ID)
+	if err != nil {
+		panic(err)
+	}
+	return params
+}
+
+// dexAsset converts the swap parameters to the dex.Asset used by the wallets
+// to fund orders.
+func (a *swapAsset) dexAsset() *dex.Asset {
+	params := a.params()
+	return &dex.Asset{
+		ID:           params.ID,
+		Symbol:       params.Symbol,
+		LotSize:      params.LotSize,
+		RateStep:     params.RateStep,
+		MaxFeeRate:   params.MaxFeeRate,
+		SwapSize:     params.SwapSize,
+		SwapSizeBase: params.SwapSizeBase,
+		SwapConf:     params.SwapConf,
+	}
+}
+
+// swapAssets is the registry of swappable assets, keyed by BIP ID.
+var swapAssets = make(map[uint32]*swapAsset)
+
+// registerSwapAsset registers the swap asset, which must be in the app asset
+// registry on every network.
+func registerSwapAsset(a *swapAsset) {
+	if _, dup := swapAssets[a.ID]; dup {
+		panic(fmt.Sprintf("swap asset %d registered twice", a.ID))
+	}
+	if sym := app.BipIDSymbol(a.ID); sym != a.Symbol {
+		panic(fmt.Sprintf("swap asset %d has symbol %q, expected %q", a.ID, a.Symbol, sym))
+	}
+	for _, net := range []app.Network{app.Mainnet, app.Testnet, app.Regtest} {
+		if _, err := app.AssetByID(net, a.ID); err != nil {
+			panic(fmt.Sprintf("swap asset %s: %v", a.Symbol, err))
+		}
+	}
+	swapAssets[a.ID] = a
+}
+
+// swapAssetIDs returns the BIP IDs of the registered swap assets in ascending
+// order.
+func swapAssetIDs() []uint32 {
+	ids := make([]uint32, 0, len(swapAssets))
+	for id := range swapAssets {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Other Bitcoin clones with a dcrdex client driver can be added with
+// newBTCCloneAsset, along with a blank import of their driver in main.go and
+// their registration in the app asset registry.
+func init() {
+	registerSwapAsset(newBTCCloneAsset(0, "btc", true, btcChainParams))
+	registerSwapAsset(newBTCCloneAsset(2, "ltc", false, ltcChainParams))
+	registerSwapAsset(&swapAsset{
+		ID:              42,
+		Symbol:          "dcr",
+		contractCoinID:  dcrContractCoinID,
+		contractDetails: dcrContractDetails,
+		extractSecret:   dcrExtractSecret,
+	})
+}
+
+// coinIDSize is the size of a UTXO coin ID, a 32-byte transaction hash and a
+// 4-byte output index.
+const coinIDSize = 36
+
+// toCoinID creates the coin ID for a UTXO from its transaction hash and output
+// index, the format used by the btc, ltc and dcr wallets.
+func toCoinID(txHash []byte, vout uint32) dex.Bytes {
+	coinID := make([]byte, len(txHash)+4)
+	copy(coinID, txHash)
+	binary.BigEndian.PutUint32(coinID[len(txHash):], vout)
+	return coinID
+}
+
+// findSecret returns the data push that hashes to secretHash, or nil if there
+// is none.
+func findSecret(pushes [][]byte, secretHash []byte) []byte {
+	for _, push := range pushes {
+		h := sha256.Sum256(push)
+		if bytes.Equal(h[:], secretHash) {
+			return push
+		}
+	}
+	return nil
+}
+
+// newBTCCloneAsset creates a swapAsset for Bitcoin or a Bitcoin clone, which
+// share the transaction format and contract script. segwit must match the
+// asset's wallet, which creates either P2WSH or P2SH contracts.
+func newBTCCloneAsset(assetID uint32, symbol string, segwit bool, chainParams func(dex.Network) (*btcchaincfg.Params, error)) *swapAsset {
+	return &swapAsset{
+		ID:             assetID,
+		Symbol:         symbol,
+		contractCoinID: btcContractCoinID,
+		contractDetails: func(contract []byte, net dex.Network) (*contractDetails, error) {
+			params, err := chainParams(net)
+			if err != nil {
+				return nil, err
+			}
+			sender, recipient, lockTime, secretHash, err := dexbtc.ExtractSwapDetails(contract, segwit, params)
+			if err != nil {
+				return nil, err
+			}
+			return &contractDetails{
+				sender:     sender.String(),
+				recipient:  recipient.String(),
+				lockTime:   lockTime,
+				secretHash: secretHash,
+			}, nil
+		},
+		extractSecret: btcExtractSecret,
+	}
+}
+
+func btcContractCoinID(contract, txB []byte) (dex.Bytes, error) {
+	tx := btcwire.NewMsgTx(btcwire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(txB)); err != nil {
+		return nil, fmt.Errorf("error decoding btc transaction: %w", err)
+	}
+	p2shHash := btcutil.Hash160(contract)
+	p2wshHash := sha256.Sum256(contract)
+	txHash := tx.TxHash()
+	for vout, txOut := range tx.TxOut {
+		scriptHash := dexbtc.ExtractScriptHash(txOut.PkScript)
+		if bytes.Equal(scriptHash, p2shHash) || bytes.Equal(scriptHash, p2wshHash[:]) {
+			return toCoinID(txHash[:], uint32(vout)), nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %s does not pay to the contract", txHash)
+}
+
+func btcExtractSecret(txB, secretHash []byte) ([]byte, error) {
+	tx := btcwire.NewMsgTx(btcwire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(txB)); err != nil {
+		return nil, fmt.Errorf("error decoding btc transaction: %w", err)
+	}
+	for _, txIn := range tx.TxIn {
+		// The secret is in the witness for segwit contracts, and in the
+		// signature script otherwise.
+		pushes, err := btctxscript.PushedData(txIn.SignatureScript)
+		if err != nil {
+			continue
+		}
+		if secret := findSecret(append(pushes, txIn.Witness...), secretHash); secret != nil {
+			return secret, nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %s does not reveal the secret", tx.TxHash())
+}
+
+func btcChainParams(net dex.Network) (*btcchaincfg.Params, error) {
+	switch net {
+	case dex.Mainnet:
+		return &btcchaincfg.MainNetParams, nil
+	case dex.Testnet:
+		return &btcchaincfg.TestNet3Params, nil
+	case dex.Regtest:
+		return &btcchaincfg.RegressionNetParams, nil
+	}
+	return nil, fmt.Errorf("unknown network %d", net)
+}
+
+func ltcChainParams(net dex.Network) (*btcchaincfg.Params, error) {
+	switch net {
+	case dex.Mainnet:
+		return dexltc.MainNetParams, nil
+	case dex.Testnet:
+		return dexltc.TestNet4Params, nil
+	case dex.Regtest:
+		return dexltc.RegressionNetParams, nil
+	}
+	return nil, fmt.Errorf("unknown network %d", net)
+}
+
+func dcrContractCoinID(contract, txB []byte) (dex.Bytes, error) {
+	tx := dcrwire.NewMsgTx()
+	if err := tx.FromBytes(txB); err != nil {
+		return nil, fmt.Errorf("error decoding dcr transaction: %w", err)
+	}
+	p2shHash := dcrutil.Hash160(contract)
+	txHash := tx.TxHash()
+	for vout, txOut := range tx.TxOut {
+		if bytes.Equal(dexdcr.ExtractScriptHash(txOut.PkScript), p2shHash) {
+			return toCoinID(txHash[:], uint32(vout)), nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %s does not pay to the contract", txHash)
+}
+
+func dcrExtractSecret(txB, secretHash []byte) ([]byte, error) {
+	tx := dcrwire.NewMsgTx()
+	if err := tx.FromBytes(txB); err != nil {
+		return nil, fmt.Errorf("error decoding dcr transaction: %w", err)
+	}
+	for _, txIn := range tx.TxIn {
+		pushes, err := dcrtxscript.PushedData(txIn.SignatureScript)
+		if err != nil {
+			continue
+		}
+		if secret := findSecret(pushes, secretHash); secret != nil {
+			return secret, nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %s does not reveal the secret", tx.TxHash())
+}
+
+func dcrChainParams(net dex.Network) (*dcrchaincfg.Params, error) {
+	switch net {
+	case dex.Mainnet:
+		return dcrchaincfg.MainNetParams(), nil
+	case dex.Testnet:
+		return dcrchaincfg.TestNet3Params(), nil
+	case dex.Regtest:
+		return dcrchaincfg.SimNetParams(), nil
+	}
+	return nil, fmt.Errorf("unknown network %d", net)
+}
+
+func dcrContractDetails(contract []byte, net dex.Network) (*contractDetails, error) {
+	params, err := dcrChainParams(net)
+	if err != nil {
+		return nil, err
+	}
+	sender, recipient, lockTime, secretHash, err := dexdcr.ExtractSwapDetails(contract, params)
+	if err != nil {
+		return nil, err
+	}
+	return &contractDetails{
+		sender:     sender.String(),
+		recipient:  recipient.String(),
+		lockTime:   lockTime,
+		secretHash: secretHash,
+	}, nil
+}
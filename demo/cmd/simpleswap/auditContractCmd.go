@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/dex"
+	"fmt"
+	"time"
+)
+
+type auditContractCmd struct {
+	contract   []byte
+	contractTx []byte
+}
+
+func (c *auditContractCmd) runCommand(ctx context.Context, party1WM, party2WM *walletMatcher) error {
+	assetID, coinID, err := locateContract(c.contract, c.contractTx, party1WM, party2WM)
+	if err != nil {
+		return err
+	}
+	details, err := swapAssets[assetID].contractDetails(c.contract, dexNetwork(network))
+	if err != nil {
+		return fmt.Errorf("error decoding contract: %w", err)
+	}
+	w, err := party1WM.wallet(assetID)
+	if err != nil {
+		return err
+	}
+	// Auditing checks that the contract output exists and is unspent.
+	auditInfo, err := w.AuditContract(coinID, c.contract)
+	if err != nil {
+		return fmt.Errorf("error auditing contract: %w", err)
+	}
+	coin, _ := asset.DecodeCoinID(assetID, coinID)
+	printf("Asset:              %s\n", dex.BipIDSymbol(assetID))
+	printf("Contract coin:      %s\n", coin)
+	printf("Contract value:     %d\n", auditInfo.Coin().Value())
+	printf("Recipient address:  %s\n", details.recipient)
+	printf("Refund address:     %s\n", details.sender)
+	printf("Secret hash:        %x\n", details.secretHash)
+	confs, _, err := w.Confirmations(coinID)
+	if err != nil {
+		return fmt.Errorf("error getting contract confirmations: %w", err)
+	}
+	reqConfs := requiredConfs(assetID, network)
+	printf("Confirmations:      %d of %d required on %s\n", confs, reqConfs, network)
+	expiration := auditInfo.Expiration()
+	printf("Locktime:           %v\n", expiration)
+	if remaining := time.Until(expiration); remaining > 0 {
+		printf("Locktime reached in %v\n", remaining.Truncate(time.Second))
+	} else {
+		printf("Contract refund time lock has expired\n")
+	}
+	// The audited contract is the counterparty's, the initiator's unless the
+	// state file says this is the initiator auditing the participant.
+	st, err := openSwapState(details.secretHash, roleParticipant)
+	if err != nil {
+		return err
+	}
+	cs := st.recordContract(false, assetID, c.contract, coinID, auditInfo.Coin().Value(), expiration)
+	cs.setAudited(expiration)
+	// Only the participant decides whether to participate in the initiator's
+	// contract. The locktimes depend on the coin the participant will send,
+	// which is not known yet, so each is checked.
+	if st.Role == roleParticipant {
+		for _, partAssetID := range swapAssetIDs() {
+			if partAssetID == assetID {
+				continue
+			}
+			lockTimes, err := swapLockTimes(assetID, partAssetID)
+			if err != nil {
+				return err
+			}
+			if err = checkInitiatorLockTime(expiration, participantLockTime(lockTimes), lockTimes); err != nil {
+				symbol := dex.BipIDSymbol(partAssetID)
+				printf("Do not participate in this contract with %s: %v\n", symbol, err)
+				cmdResult.addWarning(fmt.Sprintf("do not participate in this contract with %s: %v", symbol, err))
+			}
+		}
+	}
+	// participate and redeem refuse the contract until it is confirmed.
+	if confs < reqConfs {
+		printf("Wait for %d more confirmations before using this contract\n", reqConfs-confs)
+		cmdResult.addWarning(fmt.Sprintf("the contract has %d of %d required confirmations", confs, reqConfs))
+	}
+	step := st.Step
+	switch {
+	case st.Role == roleParticipant && step == stepCreated:
+		step = stepAudited
+	case st.Role == roleInitiator && step == stepInitiated:
+		step = stepParticipated
+	}
+	if err = st.setStep(step); err != nil {
+		return err
+	}
+	printf("State file:         %s\n", st.path)
+	cmdResult.setState(st)
+	cr := cmdResult.addContract(st.roleOf(cs), assetID, cs)
+	cr.Value = auditInfo.Coin().Value()
+	cr.Recipient = details.recipient
+	cr.RefundAddress = details.sender
+	cr.Confirmations = &confs
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/dex"
+	"fmt"
+	"github.com/skynet0590/inswap/app"
+	"time"
+)
+
+// findContract decodes a raw contract transaction with each swap asset's
+// transaction format, returning the assets for which the transaction pays to
+// the contract, and the contract's coin ID. Bitcoin clones share a transaction
+// format, so there may be more than one candidate asset.
+func findContract(contract, tx []byte) (assetIDs []uint32, coinID dex.Bytes) {
+	for _, assetID := range swapAssetIDs() {
+		id, err := swapAssets[assetID].contractCoinID(contract, tx)
+		if err == nil {
+			assetIDs = append(assetIDs, assetID)
+			coinID = id
+		}
+	}
+	return assetIDs, coinID
+}
+
+// locateContract finds the asset and coin ID of a contract. Since the wallets
+// do not expose the raw transactions they broadcast, the contract transaction
+// may also be given as the contract coin ID printed by initiate and
+// participate. When the asset cannot be identified from the transaction alone,
+// it is identified by auditing the contract with each configured wallet.
+func locateContract(contract, txOrCoinID []byte, wms ...*walletMatcher) (uint32, dex.Bytes, error) {
+	assetIDs, coinID := findContract(contract, txOrCoinID)
+	switch {
+	case len(assetIDs) == 1:
+		return assetIDs[0], coinID, nil
+	case len(assetIDs) == 0 && len(txOrCoinID) == coinIDSize:
+		assetIDs, coinID = swapAssetIDs(), txOrCoinID
+	case len(assetIDs) == 0:
+		return 0, nil, fmt.Errorf("contract transaction does not pay to the contract")
+	}
+	for _, assetID := range assetIDs {
+		for _, wm := range wms {
+			if wm == nil {
+				continue
+			}
+			w, err := wm.wallet(assetID)
+			if err != nil {
+				continue
+			}
+			if _, err = w.AuditContract(coinID, contract); err == nil {
+				return assetID, coinID, nil
+			}
+		}
+	}
+	return 0, nil, fmt.Errorf("no configured wallet found an unspent contract at coin ID %x", coinID)
+}
+
+// ownerWallet returns the wallet of whichever party owns the address.
+func ownerWallet(assetID uint32, addr string, wms ...*walletMatcher) (asset.Wallet, error) {
+	for _, wm := range wms {
+		if wm == nil {
+			continue
+		}
+		w, err := wm.wallet(assetID)
+		if err != nil {
+			return nil, err
+		}
+		owns, err := w.OwnsAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+		if owns {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("no configured %s wallet owns the address %s", dex.BipIDSymbol(assetID), addr)
+}
+
+// swapLockTimes returns the locktimes of a swap between the initiator's and
+// the participant's assets, the same as those of a market in the assets.
+func swapLockTimes(initAssetID, partAssetID uint32) (*app.MarketLockTimes, error) {
+	return app.SwapLockTimes(network, initAssetID, partAssetID)
+}
+
+// initiatorLockTime is the locktime of an initiator's contract sent now. The
+// initiator knows the secret, so its contract must outlive the participant's.
+func initiatorLockTime(lt *app.MarketLockTimes) time.Time {
+	return time.Now().Add(lt.Maker)
+}
+
+// participantLockTime is the locktime of a participant's contract sent now.
+func participantLockTime(lt *app.MarketLockTimes) time.Time {
+	return time.Now().Add(lt.Taker)
+}
+
+// lockTimeMargin is the minimum time between the expiration of the
+// participant's contract and that of the initiator's. It leaves the participant
+// time to find the secret and redeem if the initiator redeems just before the
+// participant's contract expires.
+func lockTimeMargin(lt *app.MarketLockTimes) time.Duration {
+	return (lt.Maker - lt.Taker) / 2
+}
+
+// checkInitiatorLockTime returns an error if the initiator's contract does not
+// expire at least lockTimeMargin after the participant's.
+func checkInitiatorLockTime(initExpiration, partExpiration time.Time, lt *app.MarketLockTimes) error {
+	minMargin := lockTimeMargin(lt)
+	if margin := initExpiration.Sub(partExpiration); margin < minMargin {
+		return fmt.Errorf("initiator contract expires at %v, only %v after the participant contract, need at least %v",
+			initExpiration, margin.Truncate(time.Second), minMargin)
+	}
+	return nil
+}
+
+// sendContract funds and broadcasts a single swap contract paying amount to the
+// recipient, returning the contract receipt and the fees paid.
+func sendContract(assetID uint32, w asset.Wallet, recipient string, amount uint64, secretHash []byte, lockTime time.Time) (asset.Receipt, uint64, error) {
+	dexAsset := swapAssets[assetID].dexAsset()
+	order := asset.Order{
+		Value:        amount,
+		MaxSwapCount: 1,
+		DEXConfig:    dexAsset,
+		Immediate:    true,
+	}
+	coins, _, err := w.FundOrder(&order)
+	if err != nil {
+		return nil, 0, err
+	}
+	swaps := asset.Swaps{
+		Inputs: coins,
+		Contracts: []*asset.Contract{{
+			Address:    recipient,
+			Value:      amount,
+			SecretHash: secretHash,
+			LockTime:   uint64(lockTime.Unix()),
+		}},
+		FeeRate: dexAsset.MaxFeeRate,
+	}
+	receipts, _, feesPaid, err := w.Swap(&swaps)
+	if err != nil {
+		if rErr := w.ReturnCoins(coins); rErr != nil {
+			printf("Error returning funding coins: %v\n", rErr)
+		}
+		return nil, 0, err
+	}
+	return receipts[0], feesPaid, nil
+}
+
+// printContract prints the contract information the counterparty needs to
+// audit and redeem it.
+func printContract(assetID uint32, receipt asset.Receipt, feesPaid uint64) {
+	coinID := receipt.Coin().ID()
+	coin, _ := asset.DecodeCoinID(assetID, coinID)
+	printf("%s contract fee: %d\n", dex.BipIDSymbol(assetID), feesPaid)
+	printf("Contract (%s): %x\n", coin, receipt.Contract())
+	printf("Contract coin ID: %x\n", coinID)
+	printf("Locktime: %v\n", receipt.Expiration())
+}
+
+// redeemContract redeems the confirmed contract with the secret into whichever
+// party's wallet is the contract recipient, returning the redemption coin and
+// fees.
+func redeemContract(assetID uint32, contract, coinID, secret []byte, wms ...*walletMatcher) (asset.Coin, uint64, error) {
+	details, err := swapAssets[assetID].contractDetails(contract, dexNetwork(network))
+	if err != nil {
+		return nil, 0, fmt.Errorf("error decoding contract: %w", err)
+	}
+	// Only the recipient can sign the redemption.
+	w, err := ownerWallet(assetID, details.recipient, wms...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !w.ValidateSecret(secret, details.secretHash) {
+		return nil, 0, fmt.Errorf("secret does not hash to the contract secret hash %x", details.secretHash)
+	}
+	auditInfo, err := w.AuditContract(coinID, contract)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error auditing contract: %w", err)
+	}
+	if err = checkConfirmations(w, assetID, coinID, network); err != nil {
+		return nil, 0, fmt.Errorf("refusing to redeem: %w", err)
+	}
+	_, redeemCoin, feesPaid, err := w.Redeem([]*asset.Redemption{{
+		Spends: auditInfo,
+		Secret: secret,
+	}})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error redeeming contract: %w", err)
+	}
+	return redeemCoin, feesPaid, nil
+}
+
+// refundContract refunds the expired contract to whichever party's wallet is
+// the contract sender, returning the refund coin.
+func refundContract(assetID uint32, contract, coinID []byte, wms ...*walletMatcher) (string, error) {
+	details, err := swapAssets[assetID].contractDetails(contract, dexNetwork(network))
+	if err != nil {
+		return "", fmt.Errorf("error decoding contract: %w", err)
+	}
+	// Only the sender can sign the refund.
+	w, err := ownerWallet(assetID, details.sender, wms...)
+	if err != nil {
+		return "", err
+	}
+	expired, lockTime, err := w.LocktimeExpired(contract)
+	if err != nil {
+		return "", fmt.Errorf("error checking contract locktime: %w", err)
+	}
+	if !expired {
+		return "", fmt.Errorf("contract locktime has not expired, refund is possible after %v (in %v)",
+			lockTime, time.Until(lockTime).Truncate(time.Second))
+	}
+	refundCoinID, err := w.Refund(coinID, contract)
+	if err != nil {
+		return "", fmt.Errorf("error refunding contract: %w", err)
+	}
+	return asset.DecodeCoinID(assetID, refundCoinID)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+type extractSecretCmd struct {
+	redemptionTx []byte
+	secretHash   []byte
+}
+
+func (c *extractSecretCmd) runCommand(ctx context.Context, party1WM, party2WM *walletMatcher) error {
+	for _, assetID := range swapAssetIDs() {
+		secret, err := swapAssets[assetID].extractSecret(c.redemptionTx, c.secretHash)
+		if err != nil {
+			continue
+		}
+		printf("Secret: %x\n", secret)
+		// Only the participant needs to extract the secret.
+		st, err := openSwapState(c.secretHash, roleParticipant)
+		if err != nil {
+			return err
+		}
+		if err = st.setSecret(secret); err != nil {
+			return err
+		}
+		step := st.Step
+		if step == stepParticipated || step == stepInitiatorRedeemed {
+			step = stepSecretExtracted
+		}
+		if err = st.setStep(step); err != nil {
+			return err
+		}
+		cmdResult.Secret = secret
+		cmdResult.setState(st)
+		return nil
+	}
+	return fmt.Errorf("transaction does not reveal the secret for secret hash %x", c.secretHash)
+}
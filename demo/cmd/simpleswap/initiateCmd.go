@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"decred.org/dcrdex/dex/encode"
+)
+
+type initiateCmd struct {
+	assetID     uint32
+	participant string
+	amount      uint64
+	// partAssetID is the asset of the participant's contract, which with the
+	// initiator's asset sets the swap locktimes.
+	partAssetID uint32
+}
+
+func (c *initiateCmd) runCommand(ctx context.Context, party1WM, party2WM *walletMatcher) error {
+	w, err := party1WM.wallet(c.assetID)
+	if err != nil {
+		return err
+	}
+	lockTimes, err := swapLockTimes(c.assetID, c.partAssetID)
+	if err != nil {
+		return err
+	}
+	secret := encode.RandomBytes(32)
+	secretHash := sha256.Sum256(secret)
+	// Save the secret before sending, so the contract can always be redeemed
+	// or refunded.
+	st, err := openSwapState(secretHash[:], roleInitiator)
+	if err != nil {
+		return err
+	}
+	if err = st.setSecret(secret); err != nil {
+		return err
+	}
+	st.Initiator = &contractState{Asset: swapAssets[c.assetID].Symbol, Value: c.amount}
+	if err = st.setStep(stepCreated); err != nil {
+		return err
+	}
+	receipt, feesPaid, err := sendContract(c.assetID, w, c.participant, c.amount, secretHash[:], initiatorLockTime(lockTimes))
+	if err != nil {
+		return err
+	}
+	st.Initiator.setContract(c.assetID, receipt.Contract(), receipt.Coin().ID(), receipt.Expiration())
+	if err = st.setStep(stepInitiated); err != nil {
+		return err
+	}
+	printf("Secret:      %x\n", secret)
+	printf("Secret hash: %x\n", secretHash)
+	printContract(c.assetID, receipt, feesPaid)
+	printf("State file: %s\n", st.path)
+	cmdResult.Secret = secret
+	cmdResult.setState(st)
+	cr := cmdResult.addContract(roleInitiator, c.assetID, st.Initiator)
+	cr.Fee = feesPaid
+	return nil
+}
@@ -1,23 +1,48 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"crypto/sha256"
 	"decred.org/dcrdex/client/asset"
 	_ "decred.org/dcrdex/client/asset/btc"
 	_ "decred.org/dcrdex/client/asset/dcr"
+	_ "decred.org/dcrdex/client/asset/ltc"
 	"decred.org/dcrdex/dex"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"github.com/decred/slog"
+	"github.com/skynet0590/inswap/app"
 	"io/ioutil"
 	"os"
-	"strconv"
 	"strings"
 )
 
+// walletMatcher is a party's wallets, keyed by asset BIP ID.
 type walletMatcher struct {
-	btcWallet asset.Wallet
-	dcrWallet asset.Wallet
+	wallets map[uint32]asset.Wallet
+}
+
+// wallet returns the party's wallet for the asset.
+func (wm *walletMatcher) wallet(assetID uint32) (asset.Wallet, error) {
+	w, ok := wm.wallets[assetID]
+	if !ok {
+		return nil, fmt.Errorf("no %s wallet configured", app.BipIDSymbol(assetID))
+	}
+	return w, nil
+}
+
+// assetIDs returns the BIP IDs of the party's wallets in ascending order.
+func (wm *walletMatcher) assetIDs() []uint32 {
+	ids := make([]uint32, 0, len(wm.wallets))
+	for _, id := range swapAssetIDs() {
+		if _, ok := wm.wallets[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 type command interface {
@@ -25,32 +50,87 @@ type command interface {
 }
 
 var (
-	flagset     = flag.NewFlagSet("", flag.ExitOnError)
-	confFlag    = flagset.String("conf", "demo/config.json", "path to wallet connection config file")
-	testnetFlag = flagset.Bool("testnet", false, "use testnet network")
+	flagset       = flag.NewFlagSet("", flag.ExitOnError)
+	confFlag      = flagset.String("conf", "demo/config.json", "path to wallet connection config file")
+	networkFlag   = flagset.String("network", "", "network to swap on: mainnet, testnet or simnet (default testnet)")
+	testnetFlag   = flagset.Bool("testnet", false, "use testnet network, the same as --network testnet")
+	dryRunFlag    = flagset.Bool("dry-run", false, "swap: estimate fees and pick funding coins without sending anything")
+	jsonFlag      = flagset.Bool("json", false, "print the result as a JSON object, with all other output on stderr")
+	logLevelFlag  = flagset.String("loglevel", "info", "wallet log level: trace, debug, info, warn, error, critical or off")
+	stateDirFlag  = flagset.String("statedir", ".", "directory of the swap state files used by resume")
+	skipConfsFlag = flagset.Bool("skip-confs", false, "participate, redeem, resume: use the counterparty's contract before it has the required confirmations")
 )
 
+// network is the network the swap contracts are made on, set by the --network
+// flag.
+var network = app.Testnet
+
+// logLevel is the level of the wallet logs written to stderr, set by the
+// --loglevel flag.
+var logLevel = dex.LevelInfo
+
+// stdinReader reads the user's answers to prompts.
+var stdinReader = bufio.NewReader(os.Stdin)
+
 func init() {
 	flagset.Usage = func() {
-		fmt.Println("Usage: simpleswap [flags] cmd [cmd args]")
-		fmt.Println()
-		fmt.Println("Commands:")
-		fmt.Println("  initiate <coin type: btc or dcr> <amount>")
-		fmt.Println("  participate <initiator address> <amount> <secret hash>")
-		fmt.Println("  redeem <contract> <contract transaction> <secret>")
-		fmt.Println("  refund <contract> <contract transaction>")
-		fmt.Println("  extractsecret <redemption transaction> <secret hash>")
-		fmt.Println("  auditcontract <contract> <contract transaction>")
-		fmt.Println()
-		fmt.Println("Flags:")
+		fmt.Fprintln(textOut, "Usage: simpleswap [flags] cmd [cmd args]")
+		fmt.Fprintln(textOut)
+		fmt.Fprintln(textOut, "Commands:")
+		fmt.Fprintln(textOut, "  swap <from coin type> <from amount> <to coin type> <to amount> [--dry-run]")
+		fmt.Fprintln(textOut, "  initiate <coin type> <participant address> <amount> <participant coin type>")
+		fmt.Fprintln(textOut, "  participate <coin type> <initiator address> <amount> <secret hash>")
+		fmt.Fprintln(textOut, "  redeem <contract> <contract transaction> <secret>")
+		fmt.Fprintln(textOut, "  refund <contract> <contract transaction>")
+		fmt.Fprintln(textOut, "  extractsecret <redemption transaction> <secret hash>")
+		fmt.Fprintln(textOut, "  auditcontract <contract> <contract transaction>")
+		fmt.Fprintln(textOut, "  resume <state file>")
+		fmt.Fprintln(textOut)
+		fmt.Fprintf(textOut, "Coin types: %s\n", strings.Join(swapAssetSymbols(), ", "))
+		fmt.Fprintln(textOut)
+		fmt.Fprintln(textOut, "The contract transaction may be given as the raw transaction or as the")
+		fmt.Fprintln(textOut, "contract coin ID printed by initiate and participate. Commands other than")
+		fmt.Fprintln(textOut, "swap use the party1 wallets, with party2 optional in the config file.")
+		fmt.Fprintln(textOut, "The swap locktimes depend on both coin types, so initiate is given the coin")
+		fmt.Fprintln(textOut, "type the participant will send.")
+		fmt.Fprintln(textOut)
+		fmt.Fprintln(textOut, "Every command records the swap in a state file in the state directory, with")
+		fmt.Fprintf(textOut, "the secret encrypted with a passphrase read from %s or prompted\n", stateFilePassEnv)
+		fmt.Fprintln(textOut, "for. resume continues the swap from the state file, or refunds the contract")
+		fmt.Fprintln(textOut, "once its locktime has passed.")
+		fmt.Fprintln(textOut)
+		fmt.Fprintln(textOut, "participate, redeem and resume refuse to use the counterparty's contract")
+		fmt.Fprintln(textOut, "before it has the swap confirmations required on the network.")
+		fmt.Fprintln(textOut)
+		fmt.Fprintln(textOut, "Flags:")
 		flagset.PrintDefaults()
 	}
 }
 
 func main() {
 	err, showUse := _main()
+	if *jsonFlag {
+		if showUse {
+			textOut = os.Stderr
+			flagset.Usage()
+			if err == nil {
+				err = fmt.Errorf("no command")
+			}
+		}
+		cmdResult.writeJSON(err)
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
 	if showUse {
+		if err != nil {
+			fmt.Println("Error: ", err)
+		}
 		flagset.Usage()
+		if err != nil {
+			os.Exit(1)
+		}
 		return
 	}
 	if err != nil {
@@ -72,8 +152,20 @@ func _main() (error, bool) {
 	switch args[0] {
 	case "swap":
 		cmdArgs = 4
+	case "initiate":
+		cmdArgs = 4
+	case "participate":
+		cmdArgs = 4
+	case "redeem":
+		cmdArgs = 3
 	case "refund":
 		cmdArgs = 2
+	case "extractsecret":
+		cmdArgs = 2
+	case "auditcontract":
+		cmdArgs = 2
+	case "resume":
+		cmdArgs = 1
 	default:
 		return fmt.Errorf("unknown command %v", args[0]), true
 	}
@@ -85,43 +177,179 @@ func _main() (error, bool) {
 	if flagset.NArg() != 0 {
 		return fmt.Errorf("unexpected argument: %s", flagset.Arg(0)), true
 	}
-	err := flagset.Parse(os.Args[1:])
-	fromWm, toWm, err := initWallet(*confFlag)
-	btcWL := fromWm.btcWallet
-	balance,_ := btcWL.Balance()
-	fmt.Printf("Balance(Available: %d, Immature: %d, Locked: %d) \n", balance.Available, balance.Immature, balance.Locked)
-	balance,_ = toWm.btcWallet.Balance()
-	fmt.Printf("Balance(Available: %d, Immature: %d, Locked: %d) \n", balance.Available, balance.Immature, balance.Locked)
-	Locked := btcWL.Locked()
-	fmt.Println("Locked: ", Locked)
-	err = btcWL.Unlock("")
-	fmt.Println(err)
-	return nil, false
+	if *jsonFlag {
+		textOut = os.Stderr
+	}
+	var err error
+	network, err = parseNetwork(*networkFlag, *testnetFlag)
 	if err != nil {
-		return err, false
+		return err, true
+	}
+	cmdResult.Command, cmdResult.Network = args[0], network.String()
+	var ok bool
+	if logLevel, ok = slog.LevelFromString(*logLevelFlag); !ok {
+		return fmt.Errorf("unknown log level %q", *logLevelFlag), true
+	}
+	if *dryRunFlag && args[0] != "swap" {
+		return fmt.Errorf("--dry-run is only supported by swap"), true
+	}
+	switch args[0] {
+	case "participate", "redeem", "resume":
+	default:
+		if *skipConfsFlag {
+			return fmt.Errorf("--skip-confs is only supported by participate, redeem and resume"), true
+		}
 	}
-
 	var cmd command
 	switch args[0] {
 	case "swap":
-		fromAmount, err := strconv.ParseFloat(args[2], 64)
-		toAmount, err := strconv.ParseFloat(args[4], 64)
+		fromAsset, err := parseCoin(args[1])
+		if err != nil {
+			return err, true
+		}
+		fromAmount, err := parseAmount(fromAsset, args[2])
+		if err != nil {
+			return err, true
+		}
+		toAsset, err := parseCoin(args[3])
 		if err != nil {
-			return fmt.Errorf("Amount must be number: ", err), false
+			return err, true
+		}
+		toAmount, err := parseAmount(toAsset, args[4])
+		if err != nil {
+			return err, true
 		}
 		cmd = &swapCmd{
-			fromCoin:   args[1],
+			fromAsset:  fromAsset,
 			fromAmount: fromAmount,
-			toCoin: args[3],
-			toAmount: toAmount,
+			toAsset:    toAsset,
+			toAmount:   toAmount,
+			dryRun:     *dryRunFlag,
+		}
+	case "initiate":
+		assetID, err := parseCoin(args[1])
+		if err != nil {
+			return err, true
 		}
+		amount, err := parseAmount(assetID, args[3])
+		if err != nil {
+			return err, true
+		}
+		partAssetID, err := parseCoin(args[4])
+		if err != nil {
+			return err, true
+		}
+		cmd = &initiateCmd{assetID: assetID, participant: args[2], amount: amount, partAssetID: partAssetID}
+	case "participate":
+		assetID, err := parseCoin(args[1])
+		if err != nil {
+			return err, true
+		}
+		amount, err := parseAmount(assetID, args[3])
+		if err != nil {
+			return err, true
+		}
+		secretHash, err := hex.DecodeString(args[4])
+		if err != nil {
+			return fmt.Errorf("failed to decode secret hash: %w", err), true
+		}
+		if len(secretHash) != sha256.Size {
+			return fmt.Errorf("secret hash has wrong size %d", len(secretHash)), true
+		}
+		cmd = &participateCmd{assetID: assetID, initiator: args[2], amount: amount, secretHash: secretHash}
+	case "redeem":
+		contract, contractTx, err := decodeContractArgs(args[1], args[2])
+		if err != nil {
+			return err, true
+		}
+		secret, err := hex.DecodeString(args[3])
+		if err != nil {
+			return fmt.Errorf("failed to decode secret: %w", err), true
+		}
+		cmd = &redeemCmd{contract: contract, contractTx: contractTx, secret: secret}
 	case "refund":
-		return fmt.Errorf("This func is not implemented yet"), false
+		contract, contractTx, err := decodeContractArgs(args[1], args[2])
+		if err != nil {
+			return err, true
+		}
+		cmd = &refundCmd{contract: contract, contractTx: contractTx}
+	case "extractsecret":
+		redemptionTx, err := hex.DecodeString(args[1])
+		if err != nil {
+			return fmt.Errorf("failed to decode redemption transaction: %w", err), true
+		}
+		secretHash, err := hex.DecodeString(args[2])
+		if err != nil {
+			return fmt.Errorf("failed to decode secret hash: %w", err), true
+		}
+		cmd = &extractSecretCmd{redemptionTx: redemptionTx, secretHash: secretHash}
+	case "auditcontract":
+		contract, contractTx, err := decodeContractArgs(args[1], args[2])
+		if err != nil {
+			return err, true
+		}
+		cmd = &auditContractCmd{contract: contract, contractTx: contractTx}
+	case "resume":
+		st, err := loadSwapState(args[1])
+		if err != nil {
+			return err, true
+		}
+		cmd = &resumeCmd{state: st}
+	}
+
+	// Only auditing, extracting the secret and dry runs are safe without
+	// confirmation.
+	readOnly := args[0] == "auditcontract" || args[0] == "extractsecret" || *dryRunFlag
+	if network == app.Mainnet && !readOnly {
+		if err = confirmMainnet(args[0]); err != nil {
+			return err, false
+		}
+	}
+
+	fromWm, toWm, err := initWallet(*confFlag)
+	if err != nil {
+		return err, false
 	}
 	err = cmd.runCommand(context.Background(), fromWm, toWm)
 	return err, false
 }
 
+// decodeContractArgs decodes the hex contract and contract transaction
+// arguments.
+func decodeContractArgs(contractArg, contractTxArg string) (contract, contractTx []byte, err error) {
+	contract, err = hex.DecodeString(contractArg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode contract: %w", err)
+	}
+	contractTx, err = hex.DecodeString(contractTxArg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode contract transaction: %w", err)
+	}
+	return contract, contractTx, nil
+}
+
+// parseCoin returns the asset ID for a coin type argument.
+func parseCoin(symbol string) (uint32, error) {
+	assetID, found := app.BipSymbolID(strings.ToLower(symbol))
+	if !found || swapAssets[assetID] == nil {
+		return 0, fmt.Errorf("unsupported coin type %q", symbol)
+	}
+	return assetID, nil
+}
+
+// parseAmount parses a coin amount into atoms exactly, with the asset's
+// decimal places and lot size.
+func parseAmount(assetID uint32, s string) (uint64, error) {
+	atoms, err := swapAssets[assetID].params().ParseAmount(s)
+	if err != nil {
+		return 0, err
+	}
+	if atoms == 0 {
+		return 0, fmt.Errorf("amount must be positive")
+	}
+	return atoms, nil
+}
+
 func checkCmdArgLength(args []string, required int) (nArgs int) {
 	if len(args) < required {
 		return 0
@@ -139,60 +367,50 @@ type configWM struct {
 	Passphrase string            `json:"passphrase"`
 }
 
-func newWalletMatcher(conf map[string]configWM) (*walletMatcher, error) {
-	btcSetting, ok := conf["btc"]
-	if !ok {
-		return nil, fmt.Errorf("Configure for btc wallet is required")
+// swapAssetSymbols returns the symbols of the swap assets.
+func swapAssetSymbols() []string {
+	ids := swapAssetIDs()
+	symbols := make([]string, 0, len(ids))
+	for _, id := range ids {
+		symbols = append(symbols, swapAssets[id].Symbol)
 	}
-	// setup btc wallet
-	btcConf := asset.WalletConfig{
-		Settings: btcSetting.Config,
-		TipChange: func(e error) {
+	return symbols
+}
 
-		},
-	}
-	btcWallet, err := asset.Setup(0, &btcConf, dex.NewLogger("BTC", dex.LevelTrace, os.Stdout), dex.Testnet)
-	if err != nil {
-		return nil, err
-	}
-	_, err = btcWallet.Connect(context.Background())
-	if err != nil {
-		return nil, err
+// newWalletMatcher sets up, connects and unlocks a wallet for each asset in the
+// party's config, which is keyed by asset symbol.
+func newWalletMatcher(conf map[string]configWM) (*walletMatcher, error) {
+	wm := &walletMatcher{
+		wallets: make(map[uint32]asset.Wallet, len(conf)),
 	}
-	if btcSetting.Passphrase != "" {
-		if err = btcWallet.Unlock(btcSetting.Passphrase); err != nil {
+	for symbol, setting := range conf {
+		assetID, err := parseCoin(symbol)
+		if err != nil {
 			return nil, err
 		}
-	}
-	dcrSetting, ok := conf["dcr"]
-	if !ok {
-		return nil, fmt.Errorf("Configure for dcr wallet is required")
-	}
-	// setup dcr wallet
-	dcrConf := asset.WalletConfig{
-		Settings: dcrSetting.Config,
-		TipChange: func(e error) {
+		walletConf := asset.WalletConfig{
+			Settings: setting.Config,
+			TipChange: func(e error) {
 
-		},
-	}
-	dcrWallet, err := asset.Setup(42, &dcrConf, dex.NewLogger("DCR", dex.LevelTrace, os.Stdout), dex.Testnet)
-	if err != nil {
-		return nil, err
-	}
-	_, err = dcrWallet.Connect(context.Background())
-	if err != nil {
-		return nil, err
-	}
-	if dcrSetting.Passphrase != "" {
-		if err = dcrWallet.Unlock(dcrSetting.Passphrase); err != nil {
+			},
+		}
+		logger := dex.NewLogger(strings.ToUpper(symbol), logLevel, os.Stderr)
+		w, err := asset.Setup(assetID, &walletConf, logger, dexNetwork(network))
+		if err != nil {
+			return nil, err
+		}
+		_, err = w.Connect(context.Background())
+		if err != nil {
 			return nil, err
 		}
+		if setting.Passphrase != "" {
+			if err = w.Unlock(setting.Passphrase); err != nil {
+				return nil, err
+			}
+		}
+		wm.wallets[assetID] = w
 	}
-
-	return &walletMatcher{
-		btcWallet: btcWallet,
-		dcrWallet: dcrWallet,
-	}, nil
+	return wm, nil
 }
 
 func initWallet(confFilePath string) (fromWM, toWM *walletMatcher, err error) {
@@ -209,10 +427,12 @@ func initWallet(confFilePath string) (fromWM, toWM *walletMatcher, err error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	toWM, err = newWalletMatcher(confInfo["party2"])
-	if err != nil {
-		return nil, nil, err
+	// The standalone swap steps are run by one party, so party2 is optional.
+	if party2, ok := confInfo["party2"]; ok {
+		toWM, err = newWalletMatcher(party2)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 	return fromWM, toWM, err
 }
-
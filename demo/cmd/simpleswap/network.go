@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/dex"
+	"fmt"
+	"github.com/skynet0590/inswap/app"
+	"os"
+	"strings"
+	"time"
+)
+
+// confirmTimeout is how long to wait for a contract to reach the required
+// confirmations.
+const confirmTimeout = 3 * time.Hour
+
+// dexNetwork converts the network to the dex.Network used by the wallets and
+// the chain parameters.
+func dexNetwork(net app.Network) dex.Network {
+	switch net {
+	case app.Mainnet:
+		return dex.Mainnet
+	case app.Testnet:
+		return dex.Testnet
+	}
+	return dex.Regtest
+}
+
+// parseNetwork returns the network selected by the --network and --testnet
+// flags. --testnet is the same as --network testnet.
+func parseNetwork(netName string, testnet bool) (app.Network, error) {
+	if netName == "" {
+		return app.Testnet, nil
+	}
+	net, err := app.NetFromString(netName)
+	if err != nil {
+		return 0, err
+	}
+	if testnet && net != app.Testnet {
+		return 0, fmt.Errorf("--testnet conflicts with --network %s", netName)
+	}
+	return net, nil
+}
+
+// requiredConfs is the number of confirmations a counterparty's contract needs
+// before it is trusted, the asset's swap confirmations on the network.
+func requiredConfs(assetID uint32, net app.Network) uint32 {
+	params, err := app.AssetByID(net, assetID)
+	if err != nil {
+		panic(err)
+	}
+	return params.SwapConf
+}
+
+// waitConfirmations waits for the contract to reach the confirmations required
+// on the network, returning an error if it is spent first.
+func waitConfirmations(ctx context.Context, w asset.Wallet, assetID uint32, cs *contractState, net app.Network) error {
+	reqConfs := requiredConfs(assetID, net)
+	ctx, cancel := context.WithTimeout(ctx, confirmTimeout)
+	defer cancel()
+	lastConfs := ^uint32(0)
+	for {
+		confs, spent, err := w.Confirmations(cs.CoinID)
+		if err != nil {
+			return fmt.Errorf("error getting %s contract %s confirmations: %w", cs.Asset, cs.Coin, err)
+		}
+		if spent {
+			return fmt.Errorf("%s contract %s is already spent", cs.Asset, cs.Coin)
+		}
+		if confs >= reqConfs {
+			return nil
+		}
+		if confs != lastConfs {
+			printf("Waiting for %s contract %s confirmations, %d of %d\n", cs.Asset, cs.Coin, confs, reqConfs)
+			lastConfs = confs
+		}
+		select {
+		case <-time.After(auditRetryInterval):
+		case <-ctx.Done():
+			return fmt.Errorf("%s contract %s has %d of %d confirmations: %w", cs.Asset, cs.Coin, confs, reqConfs, ctx.Err())
+		}
+	}
+}
+
+// checkConfirmations refuses to trust the counterparty's contract before it
+// has the confirmations required on the network, unless --skip-confs is set.
+func checkConfirmations(w asset.Wallet, assetID uint32, coinID []byte, net app.Network) error {
+	confs, spent, err := w.Confirmations(coinID)
+	if err != nil {
+		return fmt.Errorf("error getting contract confirmations: %w", err)
+	}
+	if spent {
+		return fmt.Errorf("%s contract is already spent", app.BipIDSymbol(assetID))
+	}
+	reqConfs := requiredConfs(assetID, net)
+	if confs < reqConfs && !*skipConfsFlag {
+		return fmt.Errorf("%s contract has %d of %d required confirmations, try again once it is confirmed or use --skip-confs",
+			app.BipIDSymbol(assetID), confs, reqConfs)
+	}
+	return nil
+}
+
+// confirmMainnet asks the user to confirm using real funds on mainnet.
+func confirmMainnet(cmd string) error {
+	fmt.Fprintf(os.Stderr, "%s will use real funds on mainnet. Type \"yes\" to continue: ", cmd)
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil && answer == "" {
+		return fmt.Errorf("mainnet not confirmed: %w", err)
+	}
+	if strings.TrimSpace(strings.ToLower(answer)) != "yes" {
+		return fmt.Errorf("mainnet not confirmed")
+	}
+	return nil
+}
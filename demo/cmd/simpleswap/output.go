@@ -0,0 +1,145 @@
+package main
+
+import (
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/dex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// textOut is where the human-readable output goes. With --json, it goes to
+// stderr with the logs, leaving stdout for the result object.
+var textOut io.Writer = os.Stdout
+
+// printf prints human-readable output.
+func printf(format string, args ...interface{}) {
+	fmt.Fprintf(textOut, format, args...)
+}
+
+// contractResult is a contract in a command's result.
+type contractResult struct {
+	Role          string    `json:"role,omitempty"`
+	Asset         string    `json:"asset"`
+	Value         uint64    `json:"value,omitempty"`
+	Coin          string    `json:"coin,omitempty"`
+	CoinID        dex.Bytes `json:"coinID,omitempty"`
+	Contract      dex.Bytes `json:"contract,omitempty"`
+	LockTime      int64     `json:"lockTime,omitempty"`
+	Fee           uint64    `json:"fee,omitempty"`
+	Recipient     string    `json:"recipient,omitempty"`
+	RefundAddress string    `json:"refundAddress,omitempty"`
+	Confirmations *uint32   `json:"confirmations,omitempty"`
+	FundingCoins  []string  `json:"fundingCoins,omitempty"`
+	RedeemCoin    string    `json:"redeemCoin,omitempty"`
+	RedeemFee     uint64    `json:"redeemFee,omitempty"`
+	RefundCoin    string    `json:"refundCoin,omitempty"`
+	RefundFee     uint64    `json:"refundFee,omitempty"`
+	InitSize      uint64    `json:"initSize,omitempty"`
+	RedeemSize    uint64    `json:"redeemSize,omitempty"`
+	RefundSize    uint64    `json:"refundSize,omitempty"`
+}
+
+// setContract records the contract's coin and script.
+func (cr *contractResult) setContract(cs *contractState) {
+	cr.Value = cs.Value
+	cr.Coin = cs.Coin
+	cr.CoinID = cs.CoinID
+	cr.Contract = cs.Contract
+	cr.LockTime = cs.LockTime
+}
+
+// balanceResult is a wallet balance in a command's result.
+type balanceResult struct {
+	Stage     string `json:"stage"`
+	Party     string `json:"party"`
+	Asset     string `json:"asset"`
+	Available uint64 `json:"available"`
+	Immature  uint64 `json:"immature"`
+	Locked    uint64 `json:"locked"`
+	Error     string `json:"error,omitempty"`
+}
+
+// result is the structured result of a command, printed as JSON with --json.
+type result struct {
+	Command    string            `json:"command"`
+	Network    string            `json:"network"`
+	Success    bool              `json:"success"`
+	Error      string            `json:"error,omitempty"`
+	Warning    string            `json:"warning,omitempty"`
+	Step       swapStep          `json:"step,omitempty"`
+	SecretHash dex.Bytes         `json:"secretHash,omitempty"`
+	Secret     dex.Bytes         `json:"secret,omitempty"`
+	Contracts  []*contractResult `json:"contracts,omitempty"`
+	Balances   []*balanceResult  `json:"balances,omitempty"`
+	StateFile  string            `json:"stateFile,omitempty"`
+}
+
+// cmdResult is the result of the command being run.
+var cmdResult = new(result)
+
+// addWarning adds a warning to the result, after any earlier ones.
+func (r *result) addWarning(warning string) {
+	if r.Warning != "" {
+		warning = r.Warning + "; " + warning
+	}
+	r.Warning = warning
+}
+
+// contract returns the result for the role's contract in the asset, adding it
+// if there is none.
+func (r *result) contract(role string, assetID uint32) *contractResult {
+	symbol := dex.BipIDSymbol(assetID)
+	for _, cr := range r.Contracts {
+		if cr.Role == role && cr.Asset == symbol {
+			return cr
+		}
+	}
+	cr := &contractResult{Role: role, Asset: symbol}
+	r.Contracts = append(r.Contracts, cr)
+	return cr
+}
+
+// addContract records the role's contract from the swap state, returning its
+// result for further details.
+func (r *result) addContract(role string, assetID uint32, cs *contractState) *contractResult {
+	cr := r.contract(role, assetID)
+	cr.setContract(cs)
+	return cr
+}
+
+// setState records the swap state's progress.
+func (r *result) setState(st *swapState) {
+	r.SecretHash = st.SecretHash
+	r.Step = st.Step
+	r.StateFile = st.path
+}
+
+// addBalance records a wallet balance.
+func (r *result) addBalance(stage, party string, assetID uint32, balance *asset.Balance, err error) {
+	br := &balanceResult{
+		Stage: stage,
+		Party: party,
+		Asset: dex.BipIDSymbol(assetID),
+	}
+	if err != nil {
+		br.Error = err.Error()
+	} else {
+		br.Available, br.Immature, br.Locked = balance.Available, balance.Immature, balance.Locked
+	}
+	r.Balances = append(r.Balances, br)
+}
+
+// writeJSON prints the result to stdout.
+func (r *result) writeJSON(err error) {
+	r.Success = err == nil
+	if err != nil {
+		r.Error = err.Error()
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(r); encErr != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding result: %v\n", encErr)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type participateCmd struct {
+	assetID    uint32
+	initiator  string
+	amount     uint64
+	secretHash []byte
+}
+
+func (c *participateCmd) runCommand(ctx context.Context, party1WM, party2WM *walletMatcher) error {
+	w, err := party1WM.wallet(c.assetID)
+	if err != nil {
+		return err
+	}
+	st, err := openSwapState(c.secretHash, roleParticipant)
+	if err != nil {
+		return err
+	}
+	if st.Role != roleParticipant {
+		return fmt.Errorf("the state file %s is for the %s of the swap", st.path, st.Role)
+	}
+	if st.ownContract().sent() {
+		return fmt.Errorf("already participated in the swap with contract %s, see %s", st.ownContract().Coin, st.path)
+	}
+	// The initiator's contract must be audited and confirmed, and must expire
+	// long enough after the participant's to leave time to redeem it.
+	if !st.Initiator.audited() {
+		return fmt.Errorf("audit the initiator's contract with auditcontract before participating")
+	}
+	initAssetID, err := st.Initiator.assetID()
+	if err != nil {
+		return err
+	}
+	initWallet, err := party1WM.wallet(initAssetID)
+	if err != nil {
+		return err
+	}
+	if err = checkConfirmations(initWallet, initAssetID, st.Initiator.CoinID, network); err != nil {
+		return fmt.Errorf("refusing to participate: %w", err)
+	}
+	lockTimes, err := swapLockTimes(initAssetID, c.assetID)
+	if err != nil {
+		return err
+	}
+	lockTime := participantLockTime(lockTimes)
+	if err = checkInitiatorLockTime(time.Unix(st.Initiator.AuditedLockTime, 0), lockTime, lockTimes); err != nil {
+		return fmt.Errorf("refusing to participate: %w", err)
+	}
+	receipt, feesPaid, err := sendContract(c.assetID, w, c.initiator, c.amount, c.secretHash, lockTime)
+	if err != nil {
+		return err
+	}
+	st.recordContract(true, c.assetID, receipt.Contract(), receipt.Coin().ID(), c.amount, receipt.Expiration())
+	if err = st.setStep(stepParticipated); err != nil {
+		return err
+	}
+	printContract(c.assetID, receipt, feesPaid)
+	printf("State file: %s\n", st.path)
+	cmdResult.setState(st)
+	cr := cmdResult.addContract(roleParticipant, c.assetID, st.Participant)
+	cr.Fee = feesPaid
+	return nil
+}
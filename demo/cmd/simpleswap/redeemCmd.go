@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"decred.org/dcrdex/dex"
+	"fmt"
+)
+
+type redeemCmd struct {
+	contract   []byte
+	contractTx []byte
+	secret     []byte
+}
+
+func (c *redeemCmd) runCommand(ctx context.Context, party1WM, party2WM *walletMatcher) error {
+	assetID, coinID, err := locateContract(c.contract, c.contractTx, party1WM, party2WM)
+	if err != nil {
+		return err
+	}
+	details, err := swapAssets[assetID].contractDetails(c.contract, dexNetwork(network))
+	if err != nil {
+		return fmt.Errorf("error decoding contract: %w", err)
+	}
+	redeemCoin, feesPaid, err := redeemContract(assetID, c.contract, coinID, c.secret, party1WM, party2WM)
+	if err != nil {
+		return err
+	}
+	printf("%s redemption fee: %d\n", dex.BipIDSymbol(assetID), feesPaid)
+	printf("Redeemed %d to %s\n", redeemCoin.Value(), redeemCoin)
+
+	// The redeemed contract is the counterparty's. The participant redeeming
+	// completes the swap. The contract value is not known, so a contract new
+	// to the state is recorded without it.
+	st, err := openSwapState(details.secretHash, roleParticipant)
+	if err != nil {
+		return err
+	}
+	cs := st.recordContract(false, assetID, c.contract, coinID, 0, details.expiration())
+	cs.RedeemCoin = redeemCoin.String()
+	if err = st.setSecret(c.secret); err != nil {
+		return err
+	}
+	step := stepComplete
+	if cs == st.Participant {
+		step = stepInitiatorRedeemed
+	}
+	if err = st.setStep(step); err != nil {
+		return err
+	}
+	cmdResult.setState(st)
+	cr := cmdResult.addContract(st.roleOf(cs), assetID, cs)
+	cr.RedeemCoin = cs.RedeemCoin
+	cr.RedeemFee = feesPaid
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"decred.org/dcrdex/dex"
+	"fmt"
+)
+
+type refundCmd struct {
+	contract   []byte
+	contractTx []byte
+}
+
+func (c *refundCmd) runCommand(ctx context.Context, party1WM, party2WM *walletMatcher) error {
+	assetID, coinID, err := locateContract(c.contract, c.contractTx, party1WM, party2WM)
+	if err != nil {
+		return err
+	}
+	details, err := swapAssets[assetID].contractDetails(c.contract, dexNetwork(network))
+	if err != nil {
+		return fmt.Errorf("error decoding contract: %w", err)
+	}
+	refundCoin, err := refundContract(assetID, c.contract, coinID, party1WM, party2WM)
+	if err != nil {
+		return err
+	}
+	printf("Refunded %s contract, refund coin: %s\n", dex.BipIDSymbol(assetID), refundCoin)
+
+	st, err := openSwapState(details.secretHash, roleInitiator)
+	if err != nil {
+		return err
+	}
+	// The refund value is not known, so a contract new to the state is
+	// recorded without it.
+	cs := st.recordContract(true, assetID, c.contract, coinID, 0, details.expiration())
+	cs.RefundCoin = refundCoin
+	if err = st.setStep(st.stepAfterRefund()); err != nil {
+		return err
+	}
+	cmdResult.setState(st)
+	cr := cmdResult.addContract(st.roleOf(cs), assetID, cs)
+	cr.RefundCoin = refundCoin
+	return nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"decred.org/dcrdex/client/asset"
+	"fmt"
+	"time"
+)
+
+// resumeFindTimeout is how long resume looks for the initiator's redemption of
+// the participant's contract, which may not have happened yet.
+const resumeFindTimeout = 30 * time.Second
+
+type resumeCmd struct {
+	state *swapState
+}
+
+// resumeAction is what resume does next for an initiator or participant.
+type resumeAction int
+
+const (
+	// resumeNone is a swap with nothing left for resume to do.
+	resumeNone resumeAction = iota
+	// resumeRedeem redeems the counterparty's contract with the secret.
+	resumeRedeem
+	// resumeWait waits for the counterparty until the own contract's locktime.
+	resumeWait
+	// resumeRefund refunds the own contract, whose locktime has passed.
+	resumeRefund
+)
+
+// searchesSecret is true if resume looks for the secret before deciding what
+// to do next. The participant learns the secret from the initiator's
+// redemption of the participant's contract.
+func searchesSecret(st *swapState, haveSecret bool) bool {
+	own := st.ownContract()
+	return !haveSecret && st.Role == roleParticipant && own.sent() && !own.spent()
+}
+
+// nextResumeAction decides what resume does next for an initiator or
+// participant at the time now. With the secret, the counterparty's contract is
+// redeemed. Otherwise the own contract is refunded once its locktime has passed.
+func nextResumeAction(st *swapState, haveSecret bool, now time.Time) resumeAction {
+	switch st.Step {
+	case stepComplete, stepRefunded:
+		return resumeNone
+	}
+	own, counterparty := st.ownContract(), *st.contractSlot(false)
+	if haveSecret && counterparty.sent() && !counterparty.spent() {
+		return resumeRedeem
+	}
+	if own.sent() && !own.spent() {
+		if now.Before(time.Unix(own.LockTime, 0)) {
+			return resumeWait
+		}
+		return resumeRefund
+	}
+	return resumeNone
+}
+
+// refundingSwap is true if a contract of a swap started by the swap command
+// has been refunded, after which the swap cannot complete and its other
+// contract must be refunded too.
+func refundingSwap(st *swapState) bool {
+	for _, cs := range []*contractState{st.Initiator, st.Participant} {
+		if cs.sent() && cs.RefundCoin != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// expiredContracts are the contracts of a swap started by the swap command that
+// resume refunds at the time now, those whose locktime has passed before the
+// secret is revealed by the initiator's redemption. The participant's contract
+// expires first.
+func expiredContracts(st *swapState, now time.Time) []*contractState {
+	switch st.Step {
+	case stepCreated, stepInitiated, stepAudited, stepParticipated:
+	default:
+		return nil
+	}
+	var expired []*contractState
+	for _, cs := range []*contractState{st.Participant, st.Initiator} {
+		if cs.sent() && !cs.spent() && !now.Before(time.Unix(cs.LockTime, 0)) {
+			expired = append(expired, cs)
+		}
+	}
+	return expired
+}
+
+func (c *resumeCmd) runCommand(ctx context.Context, party1WM, party2WM *walletMatcher) error {
+	st := c.state
+	defer cmdResult.setState(st)
+	printf("%s", st)
+	switch st.Step {
+	case stepComplete, stepRefunded:
+		printf("Nothing to do\n")
+		return nil
+	}
+	if st.Role == roleBoth {
+		return c.resumeSwap(ctx, party1WM, party2WM)
+	}
+
+	own, counterparty := st.ownContract(), *st.contractSlot(false)
+	secret, err := st.secret()
+	if err != nil {
+		return err
+	}
+	if searchesSecret(st, secret != nil) {
+		if secret, err = c.findSecret(ctx, own, party1WM); err != nil {
+			return err
+		}
+	}
+	switch nextResumeAction(st, secret != nil, time.Now()) {
+	case resumeRedeem:
+		assetID, err := counterparty.assetID()
+		if err != nil {
+			return err
+		}
+		redeemCoin, feesPaid, err := redeemContract(assetID, counterparty.Contract, counterparty.CoinID, secret, party1WM, party2WM)
+		if err != nil {
+			return err
+		}
+		printf("Redeemed %s contract %s in %s, fee %d\n", counterparty.Asset, counterparty.Coin, redeemCoin, feesPaid)
+		counterparty.RedeemCoin = redeemCoin.String()
+		cr := cmdResult.addContract(st.roleOf(counterparty), assetID, counterparty)
+		cr.RedeemCoin, cr.RedeemFee = counterparty.RedeemCoin, feesPaid
+		step := stepComplete
+		if st.Role == roleInitiator {
+			step = stepInitiatorRedeemed
+		}
+		return st.setStep(step)
+	case resumeWait:
+		lockTime := time.Unix(own.LockTime, 0)
+		printf("Waiting for the counterparty, %s contract %s can be refunded after %v (in %v)\n",
+			own.Asset, own.Coin, lockTime, time.Until(lockTime).Truncate(time.Second))
+		return nil
+	case resumeRefund:
+		return c.refund(own, party1WM, party2WM)
+	}
+	printf("Nothing to do at step %s, continue the swap with the %s commands\n", st.Step, st.Role)
+	return nil
+}
+
+// findSecret looks for the initiator's redemption of the participant's
+// contract, recording the secret it reveals. It returns nil if the contract is
+// not yet redeemed.
+func (c *resumeCmd) findSecret(ctx context.Context, own *contractState, party1WM *walletMatcher) ([]byte, error) {
+	st := c.state
+	assetID, err := own.assetID()
+	if err != nil {
+		return nil, err
+	}
+	w, err := party1WM.wallet(assetID)
+	if err != nil {
+		return nil, err
+	}
+	findCtx, cancel := context.WithTimeout(ctx, resumeFindTimeout)
+	defer cancel()
+	redeemCoinID, secret, err := w.FindRedemption(findCtx, own.CoinID)
+	if err != nil {
+		printf("%s contract %s redemption not found: %v\n", own.Asset, own.Coin, err)
+		return nil, nil
+	}
+	if !w.ValidateSecret(secret, st.SecretHash) {
+		return nil, fmt.Errorf("extracted secret %x does not match the secret hash %x", secret, st.SecretHash)
+	}
+	printf("Extracted secret %x\n", secret)
+	cmdResult.Secret = secret
+	own.RedeemCoin, _ = asset.DecodeCoinID(assetID, redeemCoinID)
+	if err = st.setSecret(secret); err != nil {
+		return nil, err
+	}
+	return secret, st.setStep(stepSecretExtracted)
+}
+
+// refund refunds the contract and records the refund.
+func (c *resumeCmd) refund(cs *contractState, wms ...*walletMatcher) error {
+	assetID, err := cs.assetID()
+	if err != nil {
+		return err
+	}
+	refundCoin, err := refundContract(assetID, cs.Contract, cs.CoinID, wms...)
+	if err != nil {
+		return err
+	}
+	printf("Refunded %s contract %s in %s\n", cs.Asset, cs.Coin, refundCoin)
+	cs.RefundCoin = refundCoin
+	cmdResult.addContract(c.state.roleOf(cs), assetID, cs).RefundCoin = refundCoin
+	return c.state.setStep(c.state.stepAfterRefund())
+}
+
+// resumeSwap resumes a swap started by the swap command. Until the secret is
+// revealed by the initiator's redemption, contracts whose locktime has passed
+// are refunded instead.
+func (c *resumeCmd) resumeSwap(ctx context.Context, party1WM, party2WM *walletMatcher) error {
+	st := c.state
+	if party2WM == nil {
+		return fmt.Errorf("resuming a swap requires wallets for both parties")
+	}
+	if expired := expiredContracts(st, time.Now()); len(expired) > 0 || refundingSwap(st) {
+		for _, cs := range expired {
+			if err := c.refund(cs, party1WM, party2WM); err != nil {
+				return err
+			}
+		}
+		// The swap cannot complete once a contract is refunded, so the other
+		// is refunded by a later resume once it expires.
+		for _, cs := range []*contractState{st.Participant, st.Initiator} {
+			if cs.sent() && !cs.spent() {
+				lockTime := time.Unix(cs.LockTime, 0)
+				printf("%s contract %s can be refunded after %v (in %v), resume again then\n",
+					cs.Asset, cs.Coin, lockTime, time.Until(lockTime).Truncate(time.Second))
+			}
+		}
+		return nil
+	}
+	fromAsset, err := st.Initiator.assetID()
+	if err != nil {
+		return err
+	}
+	toAsset, err := st.Participant.assetID()
+	if err != nil {
+		return err
+	}
+	swap := &swapCmd{
+		fromAsset:  fromAsset,
+		fromAmount: st.Initiator.Value,
+		toAsset:    toAsset,
+		toAmount:   st.Participant.Value,
+	}
+	initLeg, partLeg, err := swap.extractRole(party1WM, party2WM)
+	if err != nil {
+		return err
+	}
+	if err = swap.run(ctx, st, initLeg, partLeg); err != nil {
+		return err
+	}
+	printf("Final balances:\n")
+	printBalances("final", party1WM, party2WM)
+	return nil
+}
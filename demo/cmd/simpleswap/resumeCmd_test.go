@@ -0,0 +1,198 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+var testNow = time.Unix(1600000000, 0)
+
+// sentContract is a contract broadcast with a locktime d from testNow.
+func sentContract(d time.Duration) *contractState {
+	return &contractState{Asset: "btc", Value: 1e8, CoinID: []byte{1}, LockTime: testNow.Add(d).Unix()}
+}
+
+// refunded marks the contract refunded.
+func refunded(cs *contractState) *contractState {
+	cs.RefundCoin = "refund:0"
+	return cs
+}
+
+// redeemed marks the contract redeemed.
+func redeemed(cs *contractState) *contractState {
+	cs.RedeemCoin = "redeem:0"
+	return cs
+}
+
+func TestNextResumeAction(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		st         *swapState
+		haveSecret bool
+		search     bool
+		want       resumeAction
+	}{{
+		name: "initiator waits for the participant",
+		st: &swapState{Role: roleInitiator, Step: stepInitiated,
+			Initiator: sentContract(time.Hour)},
+		haveSecret: true,
+		want:       resumeWait,
+	}, {
+		name: "initiator refunds after the locktime",
+		st: &swapState{Role: roleInitiator, Step: stepInitiated,
+			Initiator: sentContract(-time.Hour)},
+		haveSecret: true,
+		want:       resumeRefund,
+	}, {
+		name: "initiator redeems the participant's contract",
+		st: &swapState{Role: roleInitiator, Step: stepParticipated,
+			Initiator: sentContract(2 * time.Hour), Participant: sentContract(time.Hour)},
+		haveSecret: true,
+		want:       resumeRedeem,
+	}, {
+		name: "initiator waits for the participant to redeem",
+		st: &swapState{Role: roleInitiator, Step: stepInitiatorRedeemed,
+			Initiator: sentContract(2 * time.Hour), Participant: redeemed(sentContract(time.Hour))},
+		haveSecret: true,
+		want:       resumeWait,
+	}, {
+		name: "participant searches for the secret",
+		st: &swapState{Role: roleParticipant, Step: stepParticipated,
+			Initiator: sentContract(2 * time.Hour), Participant: sentContract(time.Hour)},
+		search: true,
+		want:   resumeWait,
+	}, {
+		name: "participant refunds without the secret",
+		st: &swapState{Role: roleParticipant, Step: stepParticipated,
+			Initiator: sentContract(time.Hour), Participant: sentContract(-time.Hour)},
+		search: true,
+		want:   resumeRefund,
+	}, {
+		name: "participant redeems with the extracted secret",
+		st: &swapState{Role: roleParticipant, Step: stepSecretExtracted,
+			Initiator: sentContract(time.Hour), Participant: redeemed(sentContract(-time.Hour))},
+		haveSecret: true,
+		want:       resumeRedeem,
+	}, {
+		name: "participant has not participated",
+		st: &swapState{Role: roleParticipant, Step: stepAudited,
+			Initiator: sentContract(time.Hour)},
+		want: resumeNone,
+	}, {
+		name: "complete",
+		st: &swapState{Role: roleParticipant, Step: stepComplete,
+			Initiator: sentContract(time.Hour), Participant: sentContract(-time.Hour)},
+		haveSecret: true,
+		want:       resumeNone,
+	}, {
+		name: "refunded",
+		st: &swapState{Role: roleInitiator, Step: stepRefunded,
+			Initiator: sentContract(-time.Hour)},
+		haveSecret: true,
+		want:       resumeNone,
+	}} {
+		if search := searchesSecret(tt.st, tt.haveSecret); search != tt.search {
+			t.Errorf("%s: searches for the secret %v, want %v", tt.name, search, tt.search)
+		}
+		if action := nextResumeAction(tt.st, tt.haveSecret, testNow); action != tt.want {
+			t.Errorf("%s: got action %d, want %d", tt.name, action, tt.want)
+		}
+	}
+}
+
+func TestExpiredContracts(t *testing.T) {
+	initExpired, partExpired := sentContract(-time.Minute), sentContract(-time.Hour)
+	for _, tt := range []struct {
+		name      string
+		st        *swapState
+		want      []*contractState
+		refunding bool
+	}{{
+		name: "nothing sent",
+		st:   &swapState{Role: roleBoth, Step: stepCreated, Initiator: &contractState{}, Participant: &contractState{}},
+	}, {
+		name: "unexpired",
+		st:   &swapState{Role: roleBoth, Step: stepParticipated, Initiator: sentContract(2 * time.Hour), Participant: sentContract(time.Hour)},
+	}, {
+		name: "participant expired",
+		st:   &swapState{Role: roleBoth, Step: stepParticipated, Initiator: sentContract(time.Hour), Participant: partExpired},
+		want: []*contractState{partExpired},
+	}, {
+		// After the participant's refund, the swap waits for the initiator's
+		// contract to expire instead of continuing.
+		name:      "participant refunded",
+		st:        &swapState{Role: roleBoth, Step: stepParticipated, Initiator: sentContract(time.Hour), Participant: refunded(sentContract(-time.Hour))},
+		refunding: true,
+	}, {
+		name:      "participant refunded, initiator expired",
+		st:        &swapState{Role: roleBoth, Step: stepParticipated, Initiator: initExpired, Participant: refunded(sentContract(-time.Hour))},
+		want:      []*contractState{initExpired},
+		refunding: true,
+	}, {
+		name: "both expired",
+		st:   &swapState{Role: roleBoth, Step: stepAudited, Initiator: initExpired, Participant: partExpired},
+		want: []*contractState{partExpired, initExpired},
+	}, {
+		name: "initiator expired before participating",
+		st:   &swapState{Role: roleBoth, Step: stepAudited, Initiator: initExpired, Participant: &contractState{}},
+		want: []*contractState{initExpired},
+	}, {
+		// Once the secret is revealed, the swap is completed instead.
+		name: "secret revealed",
+		st:   &swapState{Role: roleBoth, Step: stepInitiatorRedeemed, Initiator: initExpired, Participant: redeemed(sentContract(-time.Hour))},
+	}} {
+		if refunding := refundingSwap(tt.st); refunding != tt.refunding {
+			t.Errorf("%s: refunding %v, want %v", tt.name, refunding, tt.refunding)
+		}
+		got := expiredContracts(tt.st, testNow)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d expired contracts, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: wrong contract %d", tt.name, i)
+			}
+		}
+	}
+}
+
+func TestStepAfterRefund(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		st   *swapState
+		want swapStep
+	}{{
+		// The swap command's initiator contract expires after the
+		// participant's, and must still be refunded.
+		name: "swap participant refunded",
+		st: &swapState{Role: roleBoth, Step: stepParticipated,
+			Initiator: sentContract(time.Hour), Participant: refunded(sentContract(-time.Hour))},
+		want: stepParticipated,
+	}, {
+		name: "swap both refunded",
+		st: &swapState{Role: roleBoth, Step: stepParticipated,
+			Initiator: refunded(sentContract(-time.Minute)), Participant: refunded(sentContract(-time.Hour))},
+		want: stepRefunded,
+	}, {
+		name: "swap initiator refunded before participating",
+		st: &swapState{Role: roleBoth, Step: stepAudited,
+			Initiator: refunded(sentContract(-time.Minute)), Participant: &contractState{}},
+		want: stepRefunded,
+	}, {
+		// The counterparty's contract is not this user's to refund.
+		name: "participant refunded",
+		st: &swapState{Role: roleParticipant, Step: stepParticipated,
+			Initiator: sentContract(time.Hour), Participant: refunded(sentContract(-time.Hour))},
+		want: stepRefunded,
+	}, {
+		name: "initiator not refunded",
+		st: &swapState{Role: roleInitiator, Step: stepInitiated,
+			Initiator: sentContract(time.Hour)},
+		want: stepInitiated,
+	}} {
+		if step := tt.st.stepAfterRefund(); step != tt.want {
+			t.Errorf("%s: got step %s, want %s", tt.name, step, tt.want)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encrypt"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/crypto/ssh/terminal"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// swapStep is the progress of a swap recorded in the state file.
+type swapStep string
+
+const (
+	// stepCreated is a swap with a secret but no contracts.
+	stepCreated swapStep = "created"
+	// stepInitiated is a swap with the initiator's contract sent.
+	stepInitiated swapStep = "initiated"
+	// stepAudited is a swap where the counterparty's contract was audited.
+	stepAudited swapStep = "audited"
+	// stepParticipated is a swap with both contracts sent.
+	stepParticipated swapStep = "participated"
+	// stepInitiatorRedeemed is a swap where the initiator redeemed the
+	// participant's contract, revealing the secret.
+	stepInitiatorRedeemed swapStep = "initiatorRedeemed"
+	// stepSecretExtracted is a swap where the participant extracted the secret
+	// from the initiator's redemption.
+	stepSecretExtracted swapStep = "secretExtracted"
+	// stepComplete is a swap where both contracts are redeemed.
+	stepComplete swapStep = "complete"
+	// stepRefunded is a swap where a contract was refunded.
+	stepRefunded swapStep = "refunded"
+)
+
+// The role of this tool's user in the swap. The swap command plays both roles.
+const (
+	roleInitiator   = "initiator"
+	roleParticipant = "participant"
+	roleBoth        = "both"
+)
+
+// stateFilePassEnv is the environment variable that may hold the state file
+// passphrase. The user is prompted for it otherwise.
+const stateFilePassEnv = "SIMPLESWAP_STATE_PASS"
+
+// contractState is one of the swap's contracts. Asset and Value are known
+// before the contract is sent.
+type contractState struct {
+	Asset    string    `json:"asset"`
+	Value    uint64    `json:"value"`
+	Contract dex.Bytes `json:"contract,omitempty"`
+	CoinID   dex.Bytes `json:"coinID,omitempty"`
+	Coin     string    `json:"coin,omitempty"`
+	LockTime int64     `json:"lockTime,omitempty"`
+	// AuditedLockTime is the locktime found by auditing the contract on
+	// chain, as opposed to the one reported by its sender.
+	AuditedLockTime int64  `json:"auditedLockTime,omitempty"`
+	RedeemCoin      string `json:"redeemCoin,omitempty"`
+	RefundCoin      string `json:"refundCoin,omitempty"`
+}
+
+// sent is true if the contract has been broadcast.
+func (cs *contractState) sent() bool {
+	return cs != nil && len(cs.CoinID) > 0
+}
+
+// spent is true if the contract has been redeemed or refunded.
+func (cs *contractState) spent() bool {
+	return cs.RedeemCoin != "" || cs.RefundCoin != ""
+}
+
+// audited is true if the contract has been audited.
+func (cs *contractState) audited() bool {
+	return cs.sent() && cs.AuditedLockTime != 0
+}
+
+// setAudited records the audited contract's locktime.
+func (cs *contractState) setAudited(expiration time.Time) {
+	cs.AuditedLockTime = expiration.Unix()
+}
+
+func (cs *contractState) assetID() (uint32, error) {
+	return parseCoin(cs.Asset)
+}
+
+// setContract records the broadcast contract.
+func (cs *contractState) setContract(assetID uint32, contract, coinID []byte, lockTime time.Time) {
+	cs.Asset = swapAssets[assetID].Symbol
+	cs.Contract = contract
+	cs.CoinID = coinID
+	cs.Coin, _ = asset.DecodeCoinID(assetID, coinID)
+	cs.LockTime = lockTime.Unix()
+}
+
+// swapState is the JSON state file written by every command, so that a swap
+// that fails part way can be resumed or refunded. The secret is encrypted with
+// a passphrase.
+type swapState struct {
+	path string
+
+	Role        string         `json:"role"`
+	Step        swapStep       `json:"step"`
+	SecretHash  dex.Bytes      `json:"secretHash"`
+	Crypter     dex.Bytes      `json:"crypter,omitempty"`
+	Secret      dex.Bytes      `json:"encryptedSecret,omitempty"`
+	Initiator   *contractState `json:"initiator,omitempty"`
+	Participant *contractState `json:"participant,omitempty"`
+	Updated     int64          `json:"updated"`
+}
+
+// stateFilePath is the state file of the swap with the secret hash in dir.
+func stateFilePath(dir string, secretHash []byte) string {
+	return filepath.Join(dir, fmt.Sprintf("simpleswap-%x.json", secretHash[:8]))
+}
+
+// openSwapState loads the state file of the swap with the secret hash from the
+// state directory, or creates a new state with the role if there is none.
+func openSwapState(secretHash []byte, role string) (*swapState, error) {
+	return swapStateForHash(*stateDirFlag, secretHash, role)
+}
+
+// loadSwapState reads a state file.
+func loadSwapState(path string) (*swapState, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	st := new(swapState)
+	if err = json.Unmarshal(b, st); err != nil {
+		return nil, fmt.Errorf("error decoding state file %s: %w", path, err)
+	}
+	st.path = path
+	return st, nil
+}
+
+// swapStateForHash loads the state file of the swap with the secret hash from
+// dir, or creates a new state with the role if there is none.
+func swapStateForHash(dir string, secretHash []byte, role string) (*swapState, error) {
+	path := stateFilePath(dir, secretHash)
+	st, err := loadSwapState(path)
+	if err == nil {
+		return st, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return &swapState{
+		path:       path,
+		Role:       role,
+		Step:       stepCreated,
+		SecretHash: secretHash,
+	}, nil
+}
+
+// save writes the state file, replacing the previous one only once the new one
+// is completely written.
+func (st *swapState) save() error {
+	st.Updated = time.Now().Unix()
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := st.path + ".tmp"
+	if err = ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, st.path)
+}
+
+// setStep records the step and saves the state file.
+func (st *swapState) setStep(step swapStep) error {
+	st.Step = step
+	if err := st.save(); err != nil {
+		return fmt.Errorf("error saving state file %s: %w", st.path, err)
+	}
+	return nil
+}
+
+// ownContract is the contract this tool's user sent, or nil for the swap
+// command, which sends both.
+func (st *swapState) ownContract() *contractState {
+	switch st.Role {
+	case roleInitiator:
+		return st.Initiator
+	case roleParticipant:
+		return st.Participant
+	}
+	return nil
+}
+
+// stepAfterRefund is the step of the swap once a contract is refunded. The
+// swap is only refunded once this tool's user has no unspent contract left,
+// which for the swap command is both contracts. Until then it keeps its step,
+// so that resume refunds the other contract once it expires.
+func (st *swapState) stepAfterRefund() swapStep {
+	contracts := []*contractState{st.ownContract()}
+	if st.Role == roleBoth {
+		contracts = []*contractState{st.Initiator, st.Participant}
+	}
+	for _, cs := range contracts {
+		if cs.sent() && !cs.spent() {
+			return st.Step
+		}
+	}
+	return stepRefunded
+}
+
+// contractFor returns the state of the contract with the coin ID, or nil if it
+// is not part of the swap.
+func (st *swapState) contractFor(coinID []byte) *contractState {
+	for _, cs := range []*contractState{st.Initiator, st.Participant} {
+		if cs.sent() && string(cs.CoinID) == string(coinID) {
+			return cs
+		}
+	}
+	return nil
+}
+
+// roleOf is the role of the party that sent the swap's contract.
+func (st *swapState) roleOf(cs *contractState) string {
+	if cs == st.Initiator {
+		return roleInitiator
+	}
+	return roleParticipant
+}
+
+// contractSlot is where the swap's own or counterparty contract is recorded,
+// based on the role. The swap command owns both, so it never uses contractSlot.
+func (st *swapState) contractSlot(own bool) **contractState {
+	if own == (st.Role == roleInitiator) {
+		return &st.Initiator
+	}
+	return &st.Participant
+}
+
+// recordContract returns the state of the contract with the coin ID, adding it
+// as the own or counterparty contract if it is not yet part of the swap.
+func (st *swapState) recordContract(own bool, assetID uint32, contract, coinID []byte, value uint64, lockTime time.Time) *contractState {
+	if cs := st.contractFor(coinID); cs != nil {
+		return cs
+	}
+	slot := st.contractSlot(own)
+	*slot = &contractState{Value: value}
+	(*slot).setContract(assetID, contract, coinID, lockTime)
+	return *slot
+}
+
+// setSecret encrypts the secret into the state.
+func (st *swapState) setSecret(secret []byte) error {
+	// Keep the passphrase of a state file that already has one.
+	pw, err := stateFilePass(len(st.Crypter) == 0)
+	if err != nil {
+		return err
+	}
+	var crypter encrypt.Crypter
+	if len(st.Crypter) > 0 {
+		crypter, err = encrypt.Deserialize(pw, st.Crypter)
+		if err != nil {
+			return fmt.Errorf("error decrypting state file, wrong passphrase? %w", err)
+		}
+	} else {
+		crypter = encrypt.NewCrypter(pw)
+	}
+	defer crypter.Close()
+	encSecret, err := crypter.Encrypt(secret)
+	if err != nil {
+		return err
+	}
+	st.Crypter = crypter.Serialize()
+	st.Secret = encSecret
+	return nil
+}
+
+// secret decrypts the secret from the state, returning nil if it is unknown.
+func (st *swapState) secret() ([]byte, error) {
+	if len(st.Secret) == 0 {
+		return nil, nil
+	}
+	pw, err := stateFilePass(false)
+	if err != nil {
+		return nil, err
+	}
+	crypter, err := encrypt.Deserialize(pw, st.Crypter)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting secret, wrong passphrase? %w", err)
+	}
+	defer crypter.Close()
+	return crypter.Decrypt(st.Secret)
+}
+
+// cachedStatePass is the passphrase once entered, so the user is only prompted
+// once per run.
+var cachedStatePass []byte
+
+// stateFilePass gets the state file passphrase from the environment or prompts
+// the user for it, asking for confirmation if it is a new passphrase.
+func stateFilePass(confirm bool) ([]byte, error) {
+	if cachedStatePass != nil {
+		return cachedStatePass, nil
+	}
+	if pw := os.Getenv(stateFilePassEnv); pw != "" {
+		cachedStatePass = []byte(pw)
+		return cachedStatePass, nil
+	}
+	pw, err := promptPass("State file passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(pw) == 0 {
+		return nil, fmt.Errorf("a state file passphrase is required, set %s or enter one", stateFilePassEnv)
+	}
+	if confirm {
+		pw2, err := promptPass("Confirm passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if string(pw) != string(pw2) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+	cachedStatePass = pw
+	return pw, nil
+}
+
+func promptPass(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	fd := int(os.Stdin.Fd())
+	if terminal.IsTerminal(fd) {
+		pw, err := terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return pw, err
+	}
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+// String describes the swap's progress.
+func (st *swapState) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Swap %s, role %s, step %s\n", hex.EncodeToString(st.SecretHash), st.Role, st.Step)
+	for _, c := range []struct {
+		name string
+		cs   *contractState
+	}{{"Initiator", st.Initiator}, {"Participant", st.Participant}} {
+		if !c.cs.sent() {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s %s contract %s, value %d, locktime %v", c.name, c.cs.Asset, c.cs.Coin,
+			c.cs.Value, time.Unix(c.cs.LockTime, 0))
+		switch {
+		case c.cs.RedeemCoin != "":
+			fmt.Fprintf(&b, ", redeemed in %s", c.cs.RedeemCoin)
+		case c.cs.RefundCoin != "":
+			fmt.Fprintf(&b, ", refunded in %s", c.cs.RefundCoin)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
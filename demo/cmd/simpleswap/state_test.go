@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// setStatePass sets the state file passphrase environment variable and clears
+// the cached passphrase, restoring both when the test ends.
+func setStatePass(t *testing.T, pw string) {
+	t.Helper()
+	old, had := os.LookupEnv(stateFilePassEnv)
+	os.Setenv(stateFilePassEnv, pw)
+	cachedStatePass = nil
+	t.Cleanup(func() {
+		if had {
+			os.Setenv(stateFilePassEnv, old)
+		} else {
+			os.Unsetenv(stateFilePassEnv)
+		}
+		cachedStatePass = nil
+	})
+}
+
+func TestSwapStateSaveLoad(t *testing.T) {
+	setStatePass(t, "correct horse")
+	dir := t.TempDir()
+	secret := bytes.Repeat([]byte{7}, 32)
+	secretHash := sha256.Sum256(secret)
+
+	st, err := swapStateForHash(dir, secretHash[:], roleInitiator)
+	if err != nil {
+		t.Fatalf("swapStateForHash error: %v", err)
+	}
+	if st.Step != stepCreated || st.Role != roleInitiator || st.path != stateFilePath(dir, secretHash[:]) {
+		t.Fatalf("unexpected new state %+v", st)
+	}
+	if err = st.setSecret(secret); err != nil {
+		t.Fatalf("setSecret error: %v", err)
+	}
+	st.Initiator = &contractState{
+		Asset:           "btc",
+		Value:           1e8,
+		Contract:        []byte{1, 2, 3},
+		CoinID:          make([]byte, 36),
+		LockTime:        1600000000,
+		AuditedLockTime: 1600000000,
+	}
+	if err = st.setStep(stepInitiated); err != nil {
+		t.Fatalf("setStep error: %v", err)
+	}
+	if fi, err := os.Stat(st.path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Fatalf("state file not written privately: %v", err)
+	}
+	if _, err = os.Stat(st.path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary state file left behind")
+	}
+
+	// An existing state is loaded, keeping its role.
+	loaded, err := swapStateForHash(dir, secretHash[:], roleParticipant)
+	if err != nil {
+		t.Fatalf("error loading state: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, st) {
+		t.Fatalf("state changed in round trip:\n%+v\n%+v", loaded, st)
+	}
+	if !loaded.Initiator.audited() || loaded.Participant.sent() {
+		t.Fatalf("contracts changed in round trip")
+	}
+
+	// The secret is encrypted, and decrypts with the passphrase of a later
+	// run.
+	if b, _ := ioutil.ReadFile(st.path); bytes.Contains(b, []byte(strings.Repeat("07", 32))) {
+		t.Fatalf("secret saved unencrypted")
+	}
+	cachedStatePass = nil
+	decrypted, err := loaded.secret()
+	if err != nil {
+		t.Fatalf("secret error: %v", err)
+	}
+	if !bytes.Equal(decrypted, secret) {
+		t.Fatalf("wrong secret %x", decrypted)
+	}
+}
+
+func TestSwapStateWrongPass(t *testing.T) {
+	setStatePass(t, "correct horse")
+	st := &swapState{path: stateFilePath(t.TempDir(), make([]byte, 32)), Step: stepCreated}
+	if err := st.setSecret([]byte{1}); err != nil {
+		t.Fatalf("setSecret error: %v", err)
+	}
+	if err := st.save(); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	loaded, err := loadSwapState(st.path)
+	if err != nil {
+		t.Fatalf("loadSwapState error: %v", err)
+	}
+
+	setStatePass(t, "battery staple")
+	if _, err = loaded.secret(); err == nil {
+		t.Fatalf("secret decrypted with the wrong passphrase")
+	}
+	// The wrong passphrase cannot replace the secret either.
+	if err = loaded.setSecret([]byte{2}); err == nil {
+		t.Fatalf("secret replaced with the wrong passphrase")
+	}
+
+	// A state without a secret needs no passphrase.
+	empty := &swapState{}
+	if secret, err := empty.secret(); err != nil || secret != nil {
+		t.Fatalf("unexpected secret %x, err = %v", secret, err)
+	}
+}
+
+func TestLoadSwapStateErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := loadSwapState(stateFilePath(dir, make([]byte, 32))); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+	path := stateFilePath(dir, []byte{1, 2, 3, 4, 5, 6, 7, 8})
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSwapState(path); err == nil {
+		t.Fatalf("corrupt state file loaded")
+	}
+	if _, err := swapStateForHash(dir, []byte{1, 2, 3, 4, 5, 6, 7, 8}, roleInitiator); err == nil {
+		t.Fatalf("corrupt state file replaced by a new state")
+	}
+}
+
+func TestStateFilePass(t *testing.T) {
+	setStatePass(t, "from env")
+	pw, err := stateFilePass(true)
+	if err != nil || string(pw) != "from env" {
+		t.Fatalf("got %q, err = %v", pw, err)
+	}
+	// The passphrase is cached for the rest of the run.
+	os.Setenv(stateFilePassEnv, "changed")
+	if pw, _ = stateFilePass(false); string(pw) != "from env" {
+		t.Fatalf("passphrase not cached, got %q", pw)
+	}
+
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("stdin is a terminal, skipping prompts")
+	}
+	defer func(r *bufio.Reader) { stdinReader = r }(stdinReader)
+	for _, tt := range []struct {
+		name    string
+		input   string
+		confirm bool
+		want    string
+	}{
+		{"prompted", "typed\n", false, "typed"},
+		{"confirmed", "typed\r\ntyped\n", true, "typed"},
+		{"mismatch", "typed\ntyped2\n", true, ""},
+		{"empty", "\n", false, ""},
+		{"no input", "", false, ""},
+	} {
+		setStatePass(t, "")
+		stdinReader = bufio.NewReader(strings.NewReader(tt.input))
+		pw, err := stateFilePass(tt.confirm)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: no error, got %q", tt.name, pw)
+			}
+			continue
+		}
+		if err != nil || string(pw) != tt.want {
+			t.Errorf("%s: got %q, err = %v", tt.name, pw, err)
+		}
+	}
+}
+
+func TestContractStateAudited(t *testing.T) {
+	var cs *contractState
+	if cs.sent() {
+		t.Fatalf("nil contract sent")
+	}
+	cs = &contractState{LockTime: 1600000000}
+	if cs.audited() {
+		t.Fatalf("unsent contract audited")
+	}
+	cs.CoinID = []byte{1}
+	if cs.audited() {
+		t.Fatalf("contract audited without an audited locktime")
+	}
+	expiration := time.Unix(1600000100, 0)
+	cs.setAudited(expiration)
+	if !cs.audited() || cs.AuditedLockTime != expiration.Unix() {
+		t.Fatalf("audited locktime not recorded")
+	}
+}
@@ -1,121 +1,314 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"decred.org/dcrdex/client/asset"
 	"decred.org/dcrdex/dex"
 	"decred.org/dcrdex/dex/encode"
+	"errors"
 	"fmt"
-	"github.com/btcsuite/btcutil"
-	"github.com/decred/dcrd/dcrutil/v3"
 	"time"
 )
 
+const (
+	auditTimeout          = 5 * time.Minute
+	auditRetryInterval    = 5 * time.Second
+	findRedemptionTimeout = 30 * time.Minute
+)
+
 type swapCmd struct {
-	fromCoin   string
-	fromAmount float64
-	toCoin     string
-	toAmount   float64
+	fromAsset  uint32
+	fromAmount uint64
+	toAsset    uint32
+	toAmount   uint64
+	// dryRun estimates the swap without sending anything.
+	dryRun bool
 }
 
-func (c *swapCmd) extractRole(party1WM, party2WM *walletMatcher) (fromDCR, toDCR, fromBTC, toBTC asset.Wallet, amountBTC btcutil.Amount, amountDCR dcrutil.Amount, err error) {
-	if c.fromCoin == "dcr" && c.toCoin == "btc" {
-		fromDCR, toDCR, fromBTC, toBTC = party1WM.dcrWallet, party2WM.dcrWallet, party2WM.btcWallet, party1WM.btcWallet
-		amountDCR, _ = dcrutil.NewAmount(c.fromAmount)
-		amountBTC, _ = btcutil.NewAmount(c.toAmount)
-		return
-	}
-	if c.fromCoin == "btc" && c.toCoin == "dcr" {
-		fromDCR, toDCR, fromBTC, toBTC = party2WM.dcrWallet, party1WM.dcrWallet, party1WM.btcWallet, party2WM.btcWallet
-		amountDCR, _ = dcrutil.NewAmount(c.toAmount)
-		amountBTC, _ = btcutil.NewAmount(c.fromAmount)
-		return
-	}
-	err = fmt.Errorf("Invalid input")
-	return
+// swapLeg is one of the two contracts of a swap, sent from the sender wallet
+// of one party to the receiver wallet of the other.
+type swapLeg struct {
+	assetID  uint32
+	symbol   string
+	amount   uint64
+	sender   asset.Wallet
+	receiver asset.Wallet
 }
 
-func getDexAsset(symbol string) dex.Asset {
-	if symbol == "dcr" {
-		return dex.Asset{
-			ID:           0,
-			Symbol:       symbol,
-			LotSize:      100000000,
-			RateStep:     100000000,
-			MaxFeeRate:   10,
-			SwapSize:     0,
-			SwapSizeBase: 0,
-			SwapConf:     4,
-		}
+func newSwapLeg(assetID uint32, amount uint64, from, to *walletMatcher) (*swapLeg, error) {
+	sender, err := from.wallet(assetID)
+	if err != nil {
+		return nil, err
 	}
-	if symbol == "btc" {
-		return dex.Asset{
-			ID:           0,
-			Symbol:       symbol,
-			LotSize:      100000,
-			RateStep:     100000,
-			MaxFeeRate:   100,
-			SwapSize:     0,
-			SwapSizeBase: 0,
-			SwapConf:     1,
-		}
+	receiver, err := to.wallet(assetID)
+	if err != nil {
+		return nil, err
 	}
-	return dex.Asset{}
+	return &swapLeg{
+		assetID:  assetID,
+		symbol:   swapAssets[assetID].Symbol,
+		amount:   amount,
+		sender:   sender,
+		receiver: receiver,
+	}, nil
 }
 
-func (c *swapCmd) swap(symbol string, fromW, toW asset.Wallet, amount uint64, secretHash []byte) (redeemScripts []dex.Bytes, receipts []asset.Receipt, feePaid uint64, err error) {
-	dexAsset := getDexAsset(symbol)
-	order := asset.Order{
-		Value:        amount,
-		MaxSwapCount: amount / dexAsset.LotSize,
-		DEXConfig:    &dexAsset,
-		Immediate:    true,
-	}
-	balance,_ := fromW.Balance()
-	fmt.Printf("Balance(Available: %d, Immature: %d, Locked: %d) \n", balance.Available, balance.Immature, balance.Locked)
-	coins, redeemScript, err := fromW.FundOrder(&order)
-	fmt.Println(err)
+// extractRole splits the swap into the initiator's leg, sent by party1 in the
+// from coin, and the participant's leg, sent by party2 in the to coin.
+func (c *swapCmd) extractRole(party1WM, party2WM *walletMatcher) (initLeg, partLeg *swapLeg, err error) {
+	if c.fromAsset == c.toAsset {
+		return nil, nil, fmt.Errorf("cannot swap %s for itself", swapAssets[c.fromAsset].Symbol)
+	}
+	initLeg, err = newSwapLeg(c.fromAsset, c.fromAmount, party1WM, party2WM)
 	if err != nil {
-		return nil, nil, 0, err
+		return nil, nil, err
 	}
-	toAddr, err := toW.Address()
+	partLeg, err = newSwapLeg(c.toAsset, c.toAmount, party2WM, party1WM)
 	if err != nil {
-		return nil, nil, 0, err
+		return nil, nil, err
 	}
-	contract := asset.Contract{
-		Address:    toAddr,
-		Value:      amount,
-		SecretHash: secretHash[:],
-		LockTime:   uint64(time.Now().Add(time.Hour * 24).UTC().Unix()),
+	return initLeg, partLeg, nil
+}
+
+func (c *swapCmd) swap(leg *swapLeg, secretHash []byte, lockTime time.Time) (receipt asset.Receipt, feePaid uint64, err error) {
+	toAddr, err := leg.receiver.Address()
+	if err != nil {
+		return nil, 0, err
 	}
-	swap := asset.Swaps{
-		Inputs:     coins,
-		Contracts:  []*asset.Contract{&contract},
-		FeeRate:    0,
-		LockChange: false,
+	return sendContract(leg.assetID, leg.sender, toAddr, leg.amount, secretHash, lockTime)
+}
+
+// audit waits for the counterparty's contract to be found by the receiving
+// wallet, and checks that it pays the expected amount to the receiver and is
+// locked with the expected secret hash.
+func (c *swapCmd) audit(ctx context.Context, leg *swapLeg, cs *contractState, secretHash []byte) (asset.AuditInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, auditTimeout)
+	defer cancel()
+	var auditInfo asset.AuditInfo
+	var err error
+	for {
+		auditInfo, err = leg.receiver.AuditContract(cs.CoinID, cs.Contract)
+		if !errors.Is(err, asset.CoinNotFoundError) {
+			break
+		}
+		select {
+		case <-time.After(auditRetryInterval):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s contract %s not found: %w", leg.symbol, cs.Coin, ctx.Err())
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error auditing %s contract %s: %w", leg.symbol, cs.Coin, err)
+	}
+	if value := auditInfo.Coin().Value(); value != leg.amount {
+		return nil, fmt.Errorf("%s contract pays %d, expected %d", leg.symbol, value, leg.amount)
+	}
+	if !bytes.Equal(auditInfo.SecretHash(), secretHash) {
+		return nil, fmt.Errorf("%s contract secret hash %x does not match the swap secret hash %x",
+			leg.symbol, auditInfo.SecretHash(), secretHash)
+	}
+	owns, err := leg.receiver.OwnsAddress(auditInfo.Recipient())
+	if err != nil {
+		return nil, err
+	}
+	if !owns {
+		return nil, fmt.Errorf("%s contract recipient %s is not the receiving wallet", leg.symbol, auditInfo.Recipient())
+	}
+	return auditInfo, nil
+}
+
+// redeem redeems the leg's contract with the secret into the receiving wallet.
+func (c *swapCmd) redeem(leg *swapLeg, auditInfo asset.AuditInfo, secret []byte) (asset.Coin, uint64, error) {
+	_, redeemCoin, feesPaid, err := leg.receiver.Redeem([]*asset.Redemption{{
+		Spends: auditInfo,
+		Secret: secret,
+	}})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error redeeming %s contract: %w", leg.symbol, err)
 	}
-	receipts,_,feePaid, err = fromW.Swap(&swap)
+	return redeemCoin, feesPaid, nil
+}
 
-	return redeemScript, receipts, feePaid, err
+// printBalances prints and records the balances of both parties' wallets at
+// the stage of the swap.
+func printBalances(stage string, party1WM, party2WM *walletMatcher) {
+	for _, p := range []struct {
+		name string
+		wm   *walletMatcher
+	}{{"party1", party1WM}, {"party2", party2WM}} {
+		for _, assetID := range p.wm.assetIDs() {
+			w, _ := p.wm.wallet(assetID)
+			balance, err := w.Balance()
+			cmdResult.addBalance(stage, p.name, assetID, balance, err)
+			if err != nil {
+				printf("  %s %s: error getting balance: %v\n", p.name, dex.BipIDSymbol(assetID), err)
+				continue
+			}
+			printf("  %s %s: Available: %d, Immature: %d, Locked: %d\n", p.name, dex.BipIDSymbol(assetID),
+				balance.Available, balance.Immature, balance.Locked)
+		}
+	}
 }
 
 func (c *swapCmd) runCommand(ctx context.Context, party1WM, party2WM *walletMatcher) error {
-	fromDCR, toDCR, fromBTC, toBTC, amountBTC, amountDCR, err := c.extractRole(party1WM, party2WM)
+	if party2WM == nil {
+		return fmt.Errorf("swap requires wallets for both parties")
+	}
+	initLeg, partLeg, err := c.extractRole(party1WM, party2WM)
 	if err != nil {
 		return err
 	}
+	if c.dryRun {
+		return c.estimate(initLeg, partLeg)
+	}
+	printf("Initial balances:\n")
+	printBalances("initial", party1WM, party2WM)
+
 	secret := encode.RandomBytes(32)
 	secretHash := sha256.Sum256(secret)
-	dcrRS, dcrReceipts, dcrFee, err := c.swap("dcr", fromDCR, toDCR, uint64(amountDCR), secretHash[:])
+	st, err := openSwapState(secretHash[:], roleBoth)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("DCR swap info: %v, %v, %v \n", dcrRS, dcrReceipts, dcrFee)
-	btcRS, btcReceipts, btcFee, err := c.swap("btc", fromBTC, toBTC, uint64(amountBTC), secretHash[:])
+	if err = st.setSecret(secret); err != nil {
+		return err
+	}
+	st.Initiator = &contractState{Asset: initLeg.symbol, Value: initLeg.amount}
+	st.Participant = &contractState{Asset: partLeg.symbol, Value: partLeg.amount}
+	if err = st.setStep(stepCreated); err != nil {
+		return err
+	}
+	printf("Initiator: created secret hash %x, state file %s\n", secretHash, st.path)
+
+	if err = c.run(ctx, st, initLeg, partLeg); err != nil {
+		return err
+	}
+	printf("Final balances:\n")
+	printBalances("final", party1WM, party2WM)
+	return nil
+}
+
+// run runs the swap from the state's step to completion, saving the state
+// after each step so that it can be resumed if interrupted.
+func (c *swapCmd) run(ctx context.Context, st *swapState, initLeg, partLeg *swapLeg) error {
+	secretHash := st.SecretHash
+	defer cmdResult.setState(st)
+	lockTimes, err := swapLockTimes(initLeg.assetID, partLeg.assetID)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("BTC swap info: %v, %v, %v \n", btcRS, btcReceipts, btcFee)
+	for st.Step != stepComplete {
+		var next swapStep
+		switch st.Step {
+		case stepCreated:
+			receipt, fee, err := c.swap(initLeg, secretHash, initiatorLockTime(lockTimes))
+			if err != nil {
+				return fmt.Errorf("initiator failed to send %s contract: %w", initLeg.symbol, err)
+			}
+			st.Initiator.setContract(initLeg.assetID, receipt.Contract(), receipt.Coin().ID(), receipt.Expiration())
+			printf("Initiator: sent %s contract %s, fee %d\n", initLeg.symbol, receipt.Coin(), fee)
+			cmdResult.addContract(roleInitiator, initLeg.assetID, st.Initiator).Fee = fee
+			next = stepInitiated
+
+		case stepInitiated:
+			initAudit, err := c.audit(ctx, initLeg, st.Initiator, secretHash)
+			if err != nil {
+				return err
+			}
+			st.Initiator.setAudited(initAudit.Expiration())
+			printf("Participant: audited %s contract %s, locktime %v\n", initLeg.symbol, st.Initiator.Coin, initAudit.Expiration())
+			if err = waitConfirmations(ctx, initLeg.receiver, initLeg.assetID, st.Initiator, network); err != nil {
+				return err
+			}
+			next = stepAudited
+
+		case stepAudited:
+			if !st.Initiator.audited() {
+				return fmt.Errorf("participant refusing to participate: the %s contract was not audited", initLeg.symbol)
+			}
+			partLockTime := participantLockTime(lockTimes)
+			if err := checkInitiatorLockTime(time.Unix(st.Initiator.AuditedLockTime, 0), partLockTime, lockTimes); err != nil {
+				return fmt.Errorf("participant refusing to participate: %w", err)
+			}
+			receipt, fee, err := c.swap(partLeg, secretHash, partLockTime)
+			if err != nil {
+				return fmt.Errorf("participant failed to send %s contract: %w", partLeg.symbol, err)
+			}
+			st.Participant.setContract(partLeg.assetID, receipt.Contract(), receipt.Coin().ID(), receipt.Expiration())
+			printf("Participant: sent %s contract %s, fee %d\n", partLeg.symbol, receipt.Coin(), fee)
+			cmdResult.addContract(roleParticipant, partLeg.assetID, st.Participant).Fee = fee
+			next = stepParticipated
+
+		case stepParticipated:
+			partAudit, err := c.audit(ctx, partLeg, st.Participant, secretHash)
+			if err != nil {
+				return err
+			}
+			st.Participant.setAudited(partAudit.Expiration())
+			printf("Initiator: audited %s contract %s, locktime %v\n", partLeg.symbol, st.Participant.Coin, partAudit.Expiration())
+			if err = waitConfirmations(ctx, partLeg.receiver, partLeg.assetID, st.Participant, network); err != nil {
+				return err
+			}
+			secret, err := st.secret()
+			if err != nil {
+				return err
+			}
+			redeemCoin, fee, err := c.redeem(partLeg, partAudit, secret)
+			if err != nil {
+				return err
+			}
+			st.Participant.RedeemCoin = redeemCoin.String()
+			cr := cmdResult.addContract(roleParticipant, partLeg.assetID, st.Participant)
+			cr.RedeemCoin, cr.RedeemFee = st.Participant.RedeemCoin, fee
+			printf("Initiator: redeemed %s contract in %s, fee %d\n", partLeg.symbol, redeemCoin, fee)
+			next = stepInitiatorRedeemed
+
+		case stepInitiatorRedeemed:
+			findCtx, cancel := context.WithTimeout(ctx, findRedemptionTimeout)
+			_, extractedSecret, err := partLeg.sender.FindRedemption(findCtx, st.Participant.CoinID)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("participant failed to find the %s redemption: %w", partLeg.symbol, err)
+			}
+			if !initLeg.receiver.ValidateSecret(extractedSecret, secretHash) {
+				return fmt.Errorf("extracted secret %x does not match the secret hash %x", extractedSecret, secretHash)
+			}
+			printf("Participant: extracted secret %x\n", extractedSecret)
+			// The participant redeems with the secret it extracted, which the
+			// next step reads back from the state.
+			if err = st.setSecret(extractedSecret); err != nil {
+				return err
+			}
+			next = stepSecretExtracted
+
+		case stepSecretExtracted:
+			initAudit, err := c.audit(ctx, initLeg, st.Initiator, secretHash)
+			if err != nil {
+				return err
+			}
+			// The secret extracted from the initiator's redemption.
+			secret, err := st.secret()
+			if err != nil {
+				return err
+			}
+			redeemCoin, fee, err := c.redeem(initLeg, initAudit, secret)
+			if err != nil {
+				return err
+			}
+			st.Initiator.RedeemCoin = redeemCoin.String()
+			cr := cmdResult.addContract(roleInitiator, initLeg.assetID, st.Initiator)
+			cr.RedeemCoin, cr.RedeemFee = st.Initiator.RedeemCoin, fee
+			printf("Participant: redeemed %s contract in %s, fee %d\n", initLeg.symbol, redeemCoin, fee)
+			next = stepComplete
+
+		default:
+			return fmt.Errorf("cannot continue a swap at step %s", st.Step)
+		}
+		if err := st.setStep(next); err != nil {
+			return err
+		}
+	}
 	return nil
 }
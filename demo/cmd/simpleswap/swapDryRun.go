@@ -0,0 +1,146 @@
+package main
+
+import (
+	"decred.org/dcrdex/client/asset"
+	"fmt"
+	"time"
+)
+
+// legEstimate is the estimated cost of one leg of a swap. The sizes are those
+// of the transactions sending, redeeming and refunding the contract, from
+// which the fees are estimated.
+type legEstimate struct {
+	leg        *swapLeg
+	coins      asset.Coins
+	funded     uint64
+	initSize   uint64
+	initFee    uint64
+	redeemSize uint64
+	redeemFee  uint64
+	refundSize uint64
+	refundFee  uint64
+	lockTime   time.Time
+}
+
+// estimateLeg picks the coins that would fund the leg's contract and estimates
+// its fees at the asset's max fee rate, which sendContract also uses. The
+// wallets can only pick coins by locking them, with no estimate that leaves
+// them unlocked, so the coins are returned to the wallet immediately. If they
+// cannot be returned, the dry run fails rather than leave them locked
+// unnoticed.
+func (c *swapCmd) estimateLeg(leg *swapLeg, lockTime time.Time) (*legEstimate, error) {
+	a := swapAssets[leg.assetID]
+	params := a.params()
+	coins, _, err := leg.sender.FundOrder(&asset.Order{
+		Value:        leg.amount,
+		MaxSwapCount: 1,
+		DEXConfig:    a.dexAsset(),
+		Immediate:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error funding %s contract: %w", leg.symbol, err)
+	}
+	if err = leg.sender.ReturnCoins(coins); err != nil {
+		return nil, fmt.Errorf("error unlocking %s funding coins %v, they stay locked until the wallet is restarted: %w",
+			leg.symbol, coins, err)
+	}
+	var funded uint64
+	for _, coin := range coins {
+		funded += coin.Value()
+	}
+	// SwapSize is the size of a swap transaction with a single input, so each
+	// input adds SwapSize - SwapSizeBase.
+	inputsSize := uint64(len(coins)) * (params.SwapSize - params.SwapSizeBase)
+	initSize, err := params.SwapTxSize(1, inputsSize)
+	if err != nil {
+		return nil, fmt.Errorf("error estimating %s init size: %w", leg.symbol, err)
+	}
+	initFee, err := params.MaxSwapFees(1, inputsSize)
+	if err != nil {
+		return nil, fmt.Errorf("error estimating %s init fee: %w", leg.symbol, err)
+	}
+	redeemFee, err := params.RedeemFees(1, params.MaxFeeRate)
+	if err != nil {
+		return nil, fmt.Errorf("error estimating %s redeem fee: %w", leg.symbol, err)
+	}
+	refundFee, err := params.RefundFees(1, params.MaxFeeRate)
+	if err != nil {
+		return nil, fmt.Errorf("error estimating %s refund fee: %w", leg.symbol, err)
+	}
+	return &legEstimate{
+		leg:        leg,
+		coins:      coins,
+		funded:     funded,
+		initSize:   initSize,
+		initFee:    initFee,
+		redeemSize: params.RedeemSize,
+		redeemFee:  redeemFee,
+		refundSize: params.RefundSize,
+		refundFee:  refundFee,
+		lockTime:   lockTime,
+	}, nil
+}
+
+// record adds the estimate to the command result.
+func (e *legEstimate) record(role string) {
+	cr := cmdResult.contract(role, e.leg.assetID)
+	cr.Value = e.leg.amount
+	cr.LockTime = e.lockTime.Unix()
+	cr.Fee = e.initFee
+	cr.RedeemFee = e.redeemFee
+	cr.RefundFee = e.refundFee
+	cr.InitSize = e.initSize
+	cr.RedeemSize = e.redeemSize
+	cr.RefundSize = e.refundSize
+	for _, coin := range e.coins {
+		cr.FundingCoins = append(cr.FundingCoins, coin.String())
+	}
+}
+
+func (e *legEstimate) print(role string) {
+	printf("%s %s contract:\n", role, e.leg.symbol)
+	printf("  Amount:       %d\n", e.leg.amount)
+	printf("  Funding coins (%d, total %d):\n", len(e.coins), e.funded)
+	for _, coin := range e.coins {
+		printf("    %s: %d\n", coin, coin.Value())
+	}
+	printf("  Init fee:     %d (%d bytes)\n", e.initFee, e.initSize)
+	printf("  Redeem fee:   %d (%d bytes)\n", e.redeemFee, e.redeemSize)
+	printf("  Refund fee:   %d (%d bytes)\n", e.refundFee, e.refundSize)
+	printf("  Locktime:     %v\n", e.lockTime.Format(time.RFC3339))
+}
+
+// estimate prints the funding coins, fees and locktimes of the swap and the net
+// amounts each party would receive, without sending anything.
+func (c *swapCmd) estimate(initLeg, partLeg *swapLeg) error {
+	lockTimes, err := swapLockTimes(initLeg.assetID, partLeg.assetID)
+	if err != nil {
+		return err
+	}
+	initEst, err := c.estimateLeg(initLeg, initiatorLockTime(lockTimes))
+	if err != nil {
+		return err
+	}
+	partEst, err := c.estimateLeg(partLeg, participantLockTime(lockTimes))
+	if err != nil {
+		return err
+	}
+	printf("Dry run on %s, fees estimated at the max fee rate, nothing is broadcast\n", network)
+	initEst.print("Initiator (party1)")
+	partEst.print("Participant (party2)")
+	initEst.record(roleInitiator)
+	partEst.record(roleParticipant)
+	printf("Party1 sends %d %s plus %d fees and receives %d %s after the redeem fee\n",
+		initLeg.amount, initLeg.symbol, initEst.initFee, netAmount(partLeg.amount, partEst.redeemFee), partLeg.symbol)
+	printf("Party2 sends %d %s plus %d fees and receives %d %s after the redeem fee\n",
+		partLeg.amount, partLeg.symbol, partEst.initFee, netAmount(initLeg.amount, initEst.redeemFee), initLeg.symbol)
+	return nil
+}
+
+// netAmount is the amount received after the fee, or zero if the fee exceeds it.
+func netAmount(amount, fee uint64) uint64 {
+	if fee > amount {
+		return 0
+	}
+	return amount - fee
+}
@@ -4,6 +4,14 @@ go 1.15
 
 require (
 	decred.org/dcrdex v0.1.5
-	github.com/btcsuite/btcutil v1.0.2 // indirect
-	github.com/decred/dcrd/dcrutil/v3 v3.0.0 // indirect
+	github.com/btcsuite/btcd v0.20.1-beta.0.20200615134404-e4f59022a387
+	github.com/btcsuite/btcutil v1.0.2
+	github.com/decred/dcrd/chaincfg/v3 v3.0.0
+	github.com/decred/dcrd/crypto/blake256 v1.0.0
+	github.com/decred/dcrd/dcrec/secp256k1/v3 v3.0.0
+	github.com/decred/dcrd/dcrutil/v3 v3.0.0
+	github.com/decred/dcrd/txscript/v3 v3.0.0
+	github.com/decred/dcrd/wire v1.4.0
+	github.com/decred/slog v1.1.0
+	golang.org/x/crypto v0.0.0-20210220033148-5ea612d1eb83
 )